@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/media"
+	"github.com/google/uuid"
+)
+
+const (
+	maxAttachments   = 4
+	maxAltTextLength = 1000
+	// maxChirpUploadSize bounds the whole multipart request: the images plus some room for the other fields
+	maxChirpUploadSize = maxAttachments*media.MaxFileSize + 1<<20
+	// maxUploadMemory is how much of a multipart request is kept in memory, the rest goes to temporary files
+	maxUploadMemory = 8 << 20
+	// maxConcurrentImages is how many uploaded images are decoded at the same time at most, by all requests together
+	maxConcurrentImages = 4
+)
+
+var errTooManyAttachments = fmt.Errorf("a chirp can have at most %d images", maxAttachments)
+
+// imageSlots bounds the memory taken by decoding uploads, a request holds a slot while it processes an image.
+var imageSlots = make(chan struct{}, maxConcurrentImages)
+
+// attachmentUpload is an uploaded image ready to be stored, together with its alt text.
+type attachmentUpload struct {
+	image   media.Image
+	altText string
+}
+
+// isMultipartRequest reports whether the body of r is multipart/form-data.
+func isMultipartRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// readAttachments reads and processes the images of a multipart chirp request.
+//
+// It takes a context and the parsed multipart form, where the images are the "images" files and their alt texts
+// the "alt_text" values, in the same order. Alt texts are optional, but there can't be more of them than images.
+// The images are processed one at a time, waiting for a free slot of imageSlots for each.
+// Returns the processed images, or an error, see attachmentErrorStatus for the status code it maps to.
+func readAttachments(ctx context.Context, form *multipart.Form) ([]attachmentUpload, error) {
+	files := form.File["images"]
+	altTexts := form.Value["alt_text"]
+	if len(files) > maxAttachments {
+		return nil, errTooManyAttachments
+	}
+	if len(altTexts) > len(files) {
+		return nil, errors.New("more alt texts than images")
+	}
+
+	uploads := make([]attachmentUpload, 0, len(files))
+	for i, file := range files {
+		altText := ""
+		if i < len(altTexts) {
+			altText = strings.TrimSpace(altTexts[i])
+		}
+		if utf8.RuneCountInString(altText) > maxAltTextLength {
+			return nil, errors.New("alt text is too long")
+		}
+
+		if file.Size > media.MaxFileSize {
+			return nil, media.ErrTooLarge
+		}
+		f, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(io.LimitReader(f, media.MaxFileSize+1))
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		select {
+		case imageSlots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		image, err := media.Process(data)
+		<-imageSlots
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, attachmentUpload{
+			image:   image,
+			altText: altText,
+		})
+	}
+	return uploads, nil
+}
+
+// attachmentErrorStatus maps an error of readAttachments to an HTTP status code.
+func attachmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, media.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, media.ErrUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// storeAttachments puts uploaded images and their thumbnails into the blob store.
+//
+// It takes a context and the uploads.
+// Returns the parameters to save the attachments with, only missing the ID of the chirp,
+// or an error, in which case the blobs stored so far have been deleted again.
+func (cfg *apiConfig) storeAttachments(ctx context.Context, uploads []attachmentUpload) ([]database.CreateChirpAttachmentParams, error) {
+	attachments := make([]database.CreateChirpAttachmentParams, 0, len(uploads))
+	for i, upload := range uploads {
+		id := uuid.New()
+		extension := ".jpg"
+		if upload.image.ContentType == "image/png" {
+			extension = ".png"
+		}
+		attachment := database.CreateChirpAttachmentParams{
+			ID:              id,
+			Ordinal:         int32(i),
+			ContentType:     upload.image.ContentType,
+			StorageKey:      "attachments/" + id.String() + extension,
+			Width:           int32(upload.image.Width),
+			Height:          int32(upload.image.Height),
+			ThumbnailKey:    "attachments/" + id.String() + "_thumb" + extension,
+			ThumbnailWidth:  int32(upload.image.ThumbnailWidth),
+			ThumbnailHeight: int32(upload.image.ThumbnailHeight),
+			ByteSize:        int32(len(upload.image.Data)),
+			AltText:         upload.altText,
+		}
+
+		err := cfg.blobs.Put(ctx, attachment.StorageKey, attachment.ContentType, bytes.NewReader(upload.image.Data))
+		if err == nil {
+			err = cfg.blobs.Put(ctx, attachment.ThumbnailKey, attachment.ContentType, bytes.NewReader(upload.image.Thumbnail))
+		}
+		// a half stored attachment is cleaned up with the others
+		attachments = append(attachments, attachment)
+		if err != nil {
+			cfg.deleteBlobs(attachmentKeys(attachments))
+			return nil, err
+		}
+	}
+	return attachments, nil
+}
+
+// deleteBlobs removes blobs from the blob store.
+//
+// It takes the keys of the blobs.
+// It runs after the request that stored or deleted the blobs has failed or finished,
+// so errors are only logged, a leftover blob is harmless.
+func (cfg *apiConfig) deleteBlobs(keys []string) {
+	for _, key := range keys {
+		err := cfg.blobs.Delete(context.Background(), key)
+		if err != nil {
+			log.Printf("Couldn't delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// attachmentKeys lists the keys of the images and thumbnails of attachments.
+func attachmentKeys(attachments []database.CreateChirpAttachmentParams) []string {
+	keys := make([]string, 0, 2*len(attachments))
+	for _, attachment := range attachments {
+		keys = append(keys, attachment.StorageKey, attachment.ThumbnailKey)
+	}
+	return keys
+}
+
+// handlerMedia serves the blobs of a local blob store.
+//
+// It takes the directory of the store.
+// Returns a file server for the directory that doesn't list directory contents,
+// so the keys of attachments can't be enumerated.
+func handlerMedia(dir string) http.Handler {
+	fileServer := http.FileServer(http.Dir(dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		fileServer.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/entities"
+	"github.com/google/uuid"
+)
+
+// trendHalfLife is how long it takes for the trend score of a tag to halve when nobody uses it.
+const trendHalfLife = 6 * time.Hour
+
+// saveChirpEntities stores the entities found in the body of a chirp: its @mentions and #hashtags.
+//
+// It takes a context, the queries to run them with (usually bound to the transaction that writes the chirp)
+// and the chirp as stored.
+// Entities stored for a previous body of the chirp are replaced. A chirp that is not published has none,
+// so it neither notifies mentioned users nor counts towards trending tags until a moderator approves it.
+// A published reply notifies the author of the chirp it replies to.
+// Returns the notifications stored, and an error if any of the queries fails.
+func saveChirpEntities(ctx context.Context, q *database.Queries, chirp database.Chirp) ([]database.Notification, error) {
+	body := chirp.Body
+	if chirp.Status != chirpStatusPublished {
+		body = ""
+	}
+
+	mentionedIDs, err := saveMentions(ctx, q, chirp.ID, chirp.UserID, body)
+	if err != nil {
+		return nil, err
+	}
+	err = saveHashtags(ctx, q, chirp.ID, body)
+	if err != nil {
+		return nil, err
+	}
+	if chirp.Status != chirpStatusPublished {
+		return nil, nil
+	}
+	return notifyChirp(ctx, q, chirp, mentionedIDs)
+}
+
+// notifyChirp notifies the users a published chirp is meant for: the author of the chirp it replies to,
+// and the users it mentions.
+//
+// It takes a context, the queries to run them with, the chirp and the IDs of the users it mentions.
+// The author of the chirp replied to is notified of the reply only, not of being mentioned in it too.
+// Notifications already stored, like those of an earlier body of an edited chirp, aren't stored again.
+// Returns the notifications stored, and an error if any of the queries fails.
+func notifyChirp(ctx context.Context, q *database.Queries, chirp database.Chirp, mentionedIDs []uuid.UUID) ([]database.Notification, error) {
+	notifications := []database.Notification{}
+	replyToAuthorID := uuid.Nil
+	if chirp.ReplyToID.Valid {
+		parent, err := q.GetChirpById(ctx, chirp.ReplyToID.UUID)
+		if err != nil {
+			return nil, err
+		}
+		replyToAuthorID = parent.UserID
+		stored, err := notify(ctx, q, notificationTypeReply, chirp.UserID, chirp.ID, parent.ID, []uuid.UUID{parent.UserID})
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, stored...)
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(mentionedIDs))
+	for _, userID := range mentionedIDs {
+		if userID != replyToAuthorID {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return notifications, nil
+	}
+	// the mentions in the replies to a chirp are grouped like the replies
+	subjectChirpID := chirp.ID
+	if chirp.ReplyToID.Valid {
+		subjectChirpID = chirp.ReplyToID.UUID
+	}
+	stored, err := notify(ctx, q, notificationTypeMention, chirp.UserID, chirp.ID, subjectChirpID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	return append(notifications, stored...), nil
+}
+
+// saveMentions stores the @mentions in the body of a chirp, replacing those of a previous body.
+//
+// It takes a context, the queries to run them with, the ID of the chirp, the ID of its author and its body.
+// Only handles of existing users are mentions, other @words are left as plain text.
+// So are the handles of users who blocked the author or were blocked by them.
+// Returns the IDs of the mentioned users, and an error if any of the queries fails.
+func saveMentions(ctx context.Context, q *database.Queries, chirpID, authorID uuid.UUID, body string) ([]uuid.UUID, error) {
+	// the offsets of earlier mentions don't fit the new body
+	err := q.DeleteChirpMentions(ctx, chirpID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := entities.ParseMentions(body)
+	if len(parsed) == 0 {
+		return nil, nil
+	}
+
+	handles := make([]string, 0, len(parsed))
+	for _, mention := range parsed {
+		handles = append(handles, strings.ToLower(mention.Handle))
+	}
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	blockedIDs, err := q.GetBlockedUserIDs(ctx, database.GetBlockedUserIDsParams{
+		UserIds: userIDs,
+		UserID:  authorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	blocked := map[uuid.UUID]bool{}
+	for _, id := range blockedIDs {
+		blocked[id] = true
+	}
+
+	userIDByHandle := map[string]uuid.UUID{}
+	for _, user := range users {
+		if !blocked[user.ID] {
+			userIDByHandle[strings.ToLower(user.Handle)] = user.ID
+		}
+	}
+
+	params := database.CreateChirpMentionsParams{ChirpID: chirpID}
+	mentionedIDs := []uuid.UUID{}
+	mentioned := map[uuid.UUID]bool{}
+	for _, mention := range parsed {
+		userID, ok := userIDByHandle[strings.ToLower(mention.Handle)]
+		if !ok {
+			continue
+		}
+		if !mentioned[userID] {
+			mentioned[userID] = true
+			mentionedIDs = append(mentionedIDs, userID)
+		}
+		params.UserIds = append(params.UserIds, userID)
+		params.StartOffsets = append(params.StartOffsets, int32(mention.Start))
+		params.EndOffsets = append(params.EndOffsets, int32(mention.End))
+	}
+	if len(params.UserIds) == 0 {
+		return nil, nil
+	}
+	err = q.CreateChirpMentions(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return mentionedIDs, nil
+}
+
+// saveHashtags stores the #hashtags in the body of a chirp, replacing those of a previous body.
+//
+// It takes a context, the queries to run them with, the ID of the chirp and its body.
+// Tags new to the chirp count towards the trend score of the tag, tags it already had don't count twice.
+// Returns an error if any of the queries fails.
+func saveHashtags(ctx context.Context, q *database.Queries, chirpID uuid.UUID, body string) error {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, hashtag := range entities.ParseHashtags(body) {
+		if !seen[hashtag.Tag] {
+			seen[hashtag.Tag] = true
+			tags = append(tags, hashtag.Tag)
+		}
+	}
+
+	err := q.DeleteChirpTagsExcept(ctx, database.DeleteChirpTagsExceptParams{
+		ChirpID: chirpID,
+		Tags:    tags,
+	})
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	err = q.CreateTags(ctx, tags)
+	if err != nil {
+		return err
+	}
+	addedTags, err := q.AddChirpTags(ctx, database.AddChirpTagsParams{
+		ChirpID: chirpID,
+		Tags:    tags,
+	})
+	if err != nil {
+		return err
+	}
+	if len(addedTags) == 0 {
+		return nil
+	}
+	return q.BumpTagTrends(ctx, database.BumpTagTrendsParams{
+		HalfLifeSeconds: trendHalfLife.Seconds(),
+		Names:           addedTags,
+	})
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/database"
+)
+
+const (
+	// scheduledPublishInterval is how often the publisher looks for scheduled chirps that are due
+	scheduledPublishInterval = 15 * time.Second
+	// scheduledPublishBatch is how many due chirps are published in one transaction
+	scheduledPublishBatch = 100
+	// maxScheduleAhead is how far in the future a chirp can be scheduled
+	maxScheduleAhead = 365 * 24 * time.Hour
+)
+
+// validatePublishAt checks the time a chirp is scheduled for.
+//
+// It takes the requested publish time as a parameter.
+// Returns an error if the time is not in the future or is further ahead than maxScheduleAhead.
+func validatePublishAt(publishAt time.Time) error {
+	now := time.Now()
+	if !publishAt.After(now) {
+		return errors.New("publish_at must be in the future")
+	}
+	if publishAt.After(now.Add(maxScheduleAhead)) {
+		return errors.New("publish_at must be within a year")
+	}
+	return nil
+}
+
+// scheduledStatus returns the status a chirp is stored with, taking its publish time into account.
+//
+// It takes the status decided by the content filter and the publish time of the chirp, unset for a published chirp.
+// A chirp that would be published but has a publish time is scheduled instead, even if the time has come:
+// the publisher publishes it, with the time of publishing as its created_at, see PublishScheduledChirp.
+// Any other status is returned unchanged.
+func scheduledStatus(status string, publishAt sql.NullTime) string {
+	if status == chirpStatusPublished && publishAt.Valid {
+		return chirpStatusScheduled
+	}
+	return status
+}
+
+// runScheduledPublisher publishes the scheduled chirps when they are due, until the context is done.
+//
+// It takes a context as a parameter, and is meant to run in its own goroutine.
+// Due chirps are looked for at start, so chirps that came due while the server was down are published right away,
+// and then every scheduledPublishInterval.
+func (cfg *apiConfig) runScheduledPublisher(ctx context.Context) {
+	ticker := time.NewTicker(scheduledPublishInterval)
+	defer ticker.Stop()
+
+	for {
+		published, err := cfg.publishDueChirps(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Couldn't publish scheduled chirps: %v", err)
+		}
+		if published > 0 {
+			log.Printf("Published %d scheduled chirps", published)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDueChirps publishes every scheduled chirp whose publish time has come, a batch at a time.
+//
+// It takes a context as a parameter.
+// Returns the number of chirps published, and an error if a batch fails.
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		published, err := cfg.publishDueBatch(ctx)
+		total += published
+		if err != nil || published < scheduledPublishBatch {
+			return total, err
+		}
+	}
+}
+
+// publishDueBatch publishes up to scheduledPublishBatch due chirps in one transaction.
+//
+// It takes a context as a parameter.
+// The chirps are claimed with FOR UPDATE SKIP LOCKED, so several instances of the server can publish
+// at the same time without publishing a chirp twice or waiting for each other.
+// Returns the number of chirps published, and an error if any of the queries fails.
+func (cfg *apiConfig) publishDueBatch(ctx context.Context) (int, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	due, err := qtx.ClaimDueChirps(ctx, database.ClaimDueChirpsParams{
+		Now:   time.Now().UTC(),
+		Limit: scheduledPublishBatch,
+	})
+	if err != nil {
+		return 0, err
+	}
+	publishedChirps := make([]database.Chirp, 0, len(due))
+	notifications := []database.Notification{}
+	for _, chirp := range due {
+		published, err := qtx.PublishScheduledChirp(ctx, chirp.ID)
+		if err != nil {
+			return 0, err
+		}
+		// the mentions and hashtags of a chirp are stored once it is published
+		stored, err := saveChirpEntities(ctx, qtx, published)
+		if err != nil {
+			return 0, err
+		}
+		publishedChirps = append(publishedChirps, published)
+		notifications = append(notifications, stored...)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	cfg.publishChirps(ctx, publishedChirps)
+	cfg.publishNotifications(ctx, notifications)
+	return len(due), nil
+}
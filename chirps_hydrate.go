@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// hydrateChirps fills in the parts of chirps that are not stored in their row of the chirps table:
+// the author handle, mentions, attachments, poll, counters, per-viewer flags and the original of reposts.
+//
+// It takes a context, the ID of the user reading the chirps (uuid.Nil for anonymous readers)
+// and the chirps to fill in, which are updated in place.
+// Every field is loaded with a single query for the whole slice, so a page of chirps costs the same number of round trips as a single chirp.
+// Returns an error if any of the queries fails.
+func (cfg *apiConfig) hydrateChirps(ctx context.Context, viewerID uuid.UUID, chirps []Chirp) error {
+	err := cfg.embedOriginals(ctx, viewerID, chirps)
+	if err != nil {
+		return err
+	}
+	err = cfg.hydrateChirpCounters(ctx, viewerID, chirps)
+	if err != nil {
+		return err
+	}
+	err = cfg.hydrateAuthors(ctx, chirps)
+	if err != nil {
+		return err
+	}
+	err = cfg.hydrateMentions(ctx, chirps)
+	if err != nil {
+		return err
+	}
+	err = cfg.hydrateAttachments(ctx, chirps)
+	if err != nil {
+		return err
+	}
+	return cfg.hydratePolls(ctx, viewerID, chirps)
+}
+
+// embedOriginals loads the reposted chirps of rechirps and quote chirps.
+//
+// It takes a context, the ID of the viewer and the chirps, which are updated in place.
+// Originals that no longer exist, or are hidden from the viewer by a block or a mute, become tombstones. Originals are embedded one level deep,
+// the original of a quoted quote chirp is not loaded.
+// Returns an error if any of the queries fails.
+func (cfg *apiConfig) embedOriginals(ctx context.Context, viewerID uuid.UUID, chirps []Chirp) error {
+	originalIDs := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if chirp.RepostKind != "" && chirp.Original == nil {
+			originalIDs = append(originalIDs, chirp.repostOfID)
+		}
+	}
+	if len(originalIDs) == 0 {
+		return nil
+	}
+
+	dbOriginals, err := cfg.db.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{
+		Ids:      originalIDs,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		return err
+	}
+	originals := make([]Chirp, 0, len(dbOriginals))
+	for _, dbOriginal := range dbOriginals {
+		originals = append(originals, databaseChirpToChirp(dbOriginal))
+	}
+	err = cfg.hydrateChirpCounters(ctx, viewerID, originals)
+	if err != nil {
+		return err
+	}
+
+	originalByID := map[uuid.UUID]*Chirp{}
+	for i := range originals {
+		originalByID[originals[i].ID] = &originals[i]
+	}
+	for i := range chirps {
+		if chirps[i].RepostKind == "" || chirps[i].Original != nil {
+			continue
+		}
+		original, ok := originalByID[chirps[i].repostOfID]
+		if !ok {
+			// deleted after the repost was read
+			chirps[i].Original = &OriginalChirp{Tombstone: true}
+			continue
+		}
+		chirps[i].Original = &OriginalChirp{Chirp: original}
+	}
+	return nil
+}
+
+// hydrateChirpCounters fills in the counters and per-viewer flags of chirps.
+//
+// It takes a context, the ID of the viewer and the chirps, which are updated in place.
+// Returns an error if any of the queries fails.
+func (cfg *apiConfig) hydrateChirpCounters(ctx context.Context, viewerID uuid.UUID, chirps []Chirp) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+
+	replyCounts, err := cfg.db.CountRepliesForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	replyCountByID := map[uuid.UUID]int64{}
+	for _, row := range replyCounts {
+		replyCountByID[row.ReplyToID.UUID] = row.ReplyCount
+	}
+
+	likeCounts, err := cfg.db.CountLikesForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	likeCountByID := map[uuid.UUID]int64{}
+	for _, row := range likeCounts {
+		likeCountByID[row.ChirpID] = row.LikeCount
+	}
+
+	repostCounts, err := cfg.db.CountRepostsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	repostCountByID := map[uuid.UUID]database.CountRepostsForChirpsRow{}
+	for _, row := range repostCounts {
+		repostCountByID[row.RepostOfID.UUID] = row
+	}
+
+	likedByViewer := map[uuid.UUID]bool{}
+	bookmarkedByViewer := map[uuid.UUID]bool{}
+	if viewerID != uuid.Nil {
+		likedIDs, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+			UserID:   viewerID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range likedIDs {
+			likedByViewer[id] = true
+		}
+
+		bookmarkedIDs, err := cfg.db.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+			UserID:   viewerID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range bookmarkedIDs {
+			bookmarkedByViewer[id] = true
+		}
+	}
+
+	for i := range chirps {
+		chirps[i].ReplyCount = replyCountByID[chirps[i].ID]
+		chirps[i].LikeCount = likeCountByID[chirps[i].ID]
+		chirps[i].LikedByMe = likedByViewer[chirps[i].ID]
+		chirps[i].BookmarkedByMe = bookmarkedByViewer[chirps[i].ID]
+		chirps[i].RechirpCount = repostCountByID[chirps[i].ID].RechirpCount
+		chirps[i].QuoteCount = repostCountByID[chirps[i].ID].QuoteCount
+	}
+	return nil
+}
+
+// hydrateAuthors fills in the author handle of chirps and of the originals embedded in them.
+//
+// It takes a context and the chirps, which are updated in place.
+// Returns an error if the query fails.
+func (cfg *apiConfig) hydrateAuthors(ctx context.Context, chirps []Chirp) error {
+	withOriginals := chirpsWithOriginals(chirps)
+	if len(withOriginals) == 0 {
+		return nil
+	}
+
+	authorIDs := make([]uuid.UUID, 0, len(withOriginals))
+	for _, chirp := range withOriginals {
+		authorIDs = append(authorIDs, chirp.UserID)
+	}
+	authors, err := cfg.db.GetUsersByIDs(ctx, authorIDs)
+	if err != nil {
+		return err
+	}
+	handleByID := map[uuid.UUID]string{}
+	for _, author := range authors {
+		handleByID[author.ID] = author.Handle
+	}
+
+	for _, chirp := range withOriginals {
+		chirp.AuthorHandle = handleByID[chirp.UserID]
+	}
+	return nil
+}
+
+// hydrateMentions fills in the mentions of chirps and of the originals embedded in them.
+//
+// It takes a context and the chirps, which are updated in place.
+// Returns an error if the query fails.
+func (cfg *apiConfig) hydrateMentions(ctx context.Context, chirps []Chirp) error {
+	withOriginals := chirpsWithOriginals(chirps)
+	if len(withOriginals) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(withOriginals))
+	for _, chirp := range withOriginals {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+	rows, err := cfg.db.GetMentionsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	mentionsByID := map[uuid.UUID][]Mention{}
+	for _, row := range rows {
+		mentionsByID[row.ChirpID] = append(mentionsByID[row.ChirpID], Mention{
+			UserID: row.UserID,
+			Handle: row.Handle,
+			Start:  int(row.StartOffset),
+			End:    int(row.EndOffset),
+		})
+	}
+
+	for _, chirp := range withOriginals {
+		chirp.Mentions = mentionsByID[chirp.ID]
+		if chirp.Mentions == nil {
+			chirp.Mentions = []Mention{}
+		}
+	}
+	return nil
+}
+
+// hydrateAttachments fills in the attachments of chirps and of the originals embedded in them.
+//
+// It takes a context and the chirps, which are updated in place.
+// Returns an error if the query fails.
+func (cfg *apiConfig) hydrateAttachments(ctx context.Context, chirps []Chirp) error {
+	withOriginals := chirpsWithOriginals(chirps)
+	if len(withOriginals) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(withOriginals))
+	for _, chirp := range withOriginals {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+	rows, err := cfg.db.GetAttachmentsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	attachmentsByID := map[uuid.UUID][]Attachment{}
+	for _, row := range rows {
+		attachmentsByID[row.ChirpID] = append(attachmentsByID[row.ChirpID], Attachment{
+			ID:              row.ID,
+			URL:             cfg.blobs.URL(row.StorageKey),
+			ContentType:     row.ContentType,
+			Width:           int(row.Width),
+			Height:          int(row.Height),
+			ThumbnailURL:    cfg.blobs.URL(row.ThumbnailKey),
+			ThumbnailWidth:  int(row.ThumbnailWidth),
+			ThumbnailHeight: int(row.ThumbnailHeight),
+			AltText:         row.AltText,
+		})
+	}
+
+	for _, chirp := range withOriginals {
+		chirp.Attachments = attachmentsByID[chirp.ID]
+		if chirp.Attachments == nil {
+			chirp.Attachments = []Attachment{}
+		}
+	}
+	return nil
+}
+
+// hydratePolls fills in the polls of chirps and of the originals embedded in them.
+//
+// It takes a context, the ID of the viewer and the chirps, which are updated in place.
+// The votes of an open poll are left out unless the viewer has voted in it.
+// Returns an error if any of the queries fails.
+func (cfg *apiConfig) hydratePolls(ctx context.Context, viewerID uuid.UUID, chirps []Chirp) error {
+	withOriginals := chirpsWithOriginals(chirps)
+	if len(withOriginals) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(withOriginals))
+	for _, chirp := range withOriginals {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+	rows, err := cfg.db.GetPollsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	votedByViewer := map[uuid.UUID]uuid.UUID{}
+	if viewerID != uuid.Nil {
+		votes, err := cfg.db.GetPollVotesForUser(ctx, database.GetPollVotesForUserParams{
+			UserID:   viewerID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return err
+		}
+		for _, vote := range votes {
+			votedByViewer[vote.ChirpID] = vote.OptionID
+		}
+	}
+
+	now := time.Now()
+	pollByID := map[uuid.UUID]*Poll{}
+	for _, row := range rows {
+		poll, ok := pollByID[row.ChirpID]
+		if !ok {
+			poll = &Poll{
+				ClosesAt: row.ClosesAt,
+				Closed:   !row.ClosesAt.After(now),
+				Options:  []PollOption{},
+			}
+			if optionID, voted := votedByViewer[row.ChirpID]; voted {
+				poll.VotedOptionID = &optionID
+			}
+			if poll.Closed || poll.VotedOptionID != nil {
+				poll.TotalVotes = new(int64)
+			}
+			pollByID[row.ChirpID] = poll
+		}
+
+		option := PollOption{
+			ID:    row.ID,
+			Label: row.Label,
+		}
+		if poll.TotalVotes != nil {
+			votes := row.VoteCount
+			option.Votes = &votes
+			*poll.TotalVotes += votes
+		}
+		poll.Options = append(poll.Options, option)
+	}
+
+	for _, chirp := range withOriginals {
+		chirp.Poll = pollByID[chirp.ID]
+	}
+	return nil
+}
+
+// chirpsWithOriginals lists chirps together with the originals embedded in them.
+//
+// It takes the chirps as a parameter.
+// Returns pointers into the chirps and their originals, so they can be updated in place.
+func chirpsWithOriginals(chirps []Chirp) []*Chirp {
+	withOriginals := make([]*Chirp, 0, len(chirps))
+	for i := range chirps {
+		withOriginals = append(withOriginals, &chirps[i])
+		if chirps[i].Original != nil && chirps[i].Original.Chirp != nil {
+			withOriginals = append(withOriginals, chirps[i].Original.Chirp)
+		}
+	}
+	return withOriginals
+}
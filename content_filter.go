@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/contentfilter"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/realtime"
+	"github.com/google/uuid"
+)
+
+// The statuses of a chirp, see the chirps_status_check constraint.
+// Only published chirps are listed, searched and counted, the others are visible to their author alone.
+const (
+	chirpStatusPublished     = "published"
+	chirpStatusPendingReview = "pending_review"
+	chirpStatusRejected      = "rejected"
+	chirpStatusScheduled     = "scheduled"
+)
+
+// errChirpRejected is returned by filterChirp for a chirp using a word of a reject rule.
+var errChirpRejected = errors.New("Chirp contains a prohibited word")
+
+// defaultFilterRules are the rules used when no word list file is configured.
+var defaultFilterRules = []contentfilter.Rule{
+	{Word: "kerfuffle", Action: contentfilter.ActionMask},
+	{Word: "sharbert", Action: contentfilter.ActionMask},
+	{Word: "fornax", Action: contentfilter.ActionMask},
+}
+
+// readFilterRules reads the rules of the word list file at path.
+//
+// It takes the path of the file, an empty path means the default rules.
+// Returns the rules, or an error if the file can't be read or has an invalid rule.
+func readFilterRules(path string) ([]contentfilter.Rule, error) {
+	if path == "" {
+		return defaultFilterRules, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return contentfilter.ParseRules(file)
+}
+
+// reloadContentFilter rebuilds the content filter from the rules of the word list file and the database.
+//
+// It takes a context as a parameter.
+// Rules in the database are edited at runtime by admins, they override the rules of the file for the same word.
+// Returns an error if the rules can't be loaded, the current filter is then kept.
+func (cfg *apiConfig) reloadContentFilter(ctx context.Context) error {
+	dbRules, err := cfg.db.ListContentFilterRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules := make([]contentfilter.Rule, 0, len(cfg.fileFilterRules)+len(dbRules))
+	rules = append(rules, cfg.fileFilterRules...)
+	for _, dbRule := range dbRules {
+		rules = append(rules, contentfilter.Rule{
+			Word:   dbRule.Word,
+			Action: contentfilter.Action(dbRule.Action),
+		})
+	}
+
+	filter, err := contentfilter.New(rules)
+	if err != nil {
+		return err
+	}
+	cfg.contentFilter.Store(filter)
+	return nil
+}
+
+// contentFilterChanged reloads the content filter after an admin edited its rules,
+// and tells the other instances of the server to reload theirs.
+//
+// It takes a context as a parameter.
+// Returns an error if the rules can't be loaded, the other instances are then not told.
+func (cfg *apiConfig) contentFilterChanged(ctx context.Context) error {
+	err := cfg.reloadContentFilter(ctx)
+	if err != nil {
+		return err
+	}
+	cfg.relayEvent(ctx, realtime.Relay{Type: relayTypeContentFilterChanged})
+	return nil
+}
+
+// filterChirp runs the body of a chirp through the content filter.
+//
+// It takes the body as a parameter.
+// Returns the body with the words of mask rules masked and the status the chirp is stored with,
+// pending review if it uses a word of a moderate rule, or errChirpRejected if it uses a word of a reject rule.
+func (cfg *apiConfig) filterChirp(body string) (string, string, error) {
+	result := cfg.contentFilter.Load().Check(body)
+	switch result.Action {
+	case contentfilter.ActionReject:
+		return "", "", errChirpRejected
+	case contentfilter.ActionModerate:
+		return result.Text, chirpStatusPendingReview, nil
+	}
+	return result.Text, chirpStatusPublished, nil
+}
+
+// chirpVisibleTo reports whether a user may see a chirp: everyone sees published chirps,
+// only the author sees a chirp that is pending review or rejected.
+func chirpVisibleTo(chirp database.Chirp, viewerID uuid.UUID) bool {
+	return chirp.Status == chirpStatusPublished || chirp.UserID == viewerID
+}
+
+// validateAdminKey checks the ApiKey authorization header of a request to an admin endpoint.
+//
+// It takes the headers of the request as a parameter.
+// Returns an error if the header is missing or doesn't hold the admin key, always if no admin key is configured.
+func (cfg *apiConfig) validateAdminKey(headers http.Header) error {
+	apiKey, err := auth.GetApiKey(headers)
+	if err != nil {
+		return err
+	}
+	if cfg.adminKey == "" || subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.adminKey)) != 1 {
+		return errors.New("invalid admin API key")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// uniqueViolationCode is the Postgres error code of a unique constraint violation.
+	uniqueViolationCode = "23505"
+	// foreignKeyViolationCode is the Postgres error code of a foreign key constraint violation.
+	foreignKeyViolationCode = "23503"
+)
+
+// uniqueViolation reports which unique constraint an insert or update violated.
+//
+// It takes the error returned by the query.
+// Returns the name of the violated constraint or index, or an empty string if err is not a unique violation.
+func uniqueViolation(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode {
+		return pqErr.Constraint
+	}
+	return ""
+}
+
+// foreignKeyViolation reports which foreign key constraint an insert or update violated.
+//
+// It takes the error returned by the query.
+// Returns the name of the violated constraint, or an empty string if err is not a foreign key violation.
+func foreignKeyViolation(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolationCode {
+		return pqErr.Constraint
+	}
+	return ""
+}
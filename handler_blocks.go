@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// BlockedUser is a user the authenticated user blocked, with the time of the block.
+type BlockedUser struct {
+	UserSummary
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// MutedUser is a user the authenticated user muted, with the time of the mute.
+type MutedUser struct {
+	UserSummary
+	MutedAt time.Time `json:"muted_at"`
+}
+
+// blockedBetween reports whether either of two users blocked the other.
+//
+// It takes a context and the IDs of the two users, uuid.Nil for an anonymous reader, who is never blocked.
+// Returns an error if the query fails.
+func (cfg *apiConfig) blockedBetween(ctx context.Context, userID, otherUserID uuid.UUID) (bool, error) {
+	if userID == uuid.Nil || otherUserID == uuid.Nil {
+		return false, nil
+	}
+	return cfg.db.IsBlockedBetween(ctx, database.IsBlockedBetweenParams{
+		UserID:      userID,
+		OtherUserID: otherUserID,
+	})
+}
+
+// handlerBlockUser handles blocking a user.
+//
+// It expects a JSON payload in the request body with the field "user_id".
+// A block works both ways: neither user sees the chirps of the other, and neither can follow, reply to or mention the other.
+// Both follows between the users are removed. Blocking is idempotent, blocking a blocked user succeeds without changing anything.
+// Returns a 204 No Content response if the user is blocked, or an error response otherwise.
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if params.UserID == userID {
+		respondWithError(w, http.StatusBadRequest, "Users can't block themselves", errors.New("self block"))
+		return
+	}
+
+	_, err = cfg.db.GetUserByID(r.Context(), params.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.BlockUser(r.Context(), database.BlockUserParams{
+		BlockerID: userID,
+		BlockedID: params.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't block user", err)
+		return
+	}
+
+	err = qtx.DeleteFollowsBetween(r.Context(), database.DeleteFollowsBetweenParams{
+		FollowerID: userID,
+		FolloweeID: params.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't block user", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't block user", err)
+		return
+	}
+
+	cfg.publishHiddenUsersChanged(r.Context(), userID, params.UserID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUnblockUser handles removing the block of the user in the path.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Unblocking is idempotent, unblocking a user who is not blocked succeeds without changing anything.
+// The follows removed by the block are not restored.
+// Returns a 204 No Content response if the block is removed, or an error response otherwise.
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	blockedIDString := r.PathValue("userID")
+	blockedID, err := uuid.Parse(blockedIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	err = cfg.db.UnblockUser(r.Context(), database.UnblockUserParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unblock user", err)
+		return
+	}
+
+	cfg.publishHiddenUsersChanged(r.Context(), userID, blockedID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetBlockedUsers handles the listing of the users the authenticated user blocked, one page at a time.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The most recent blocks come first, the optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of blocked users and the cursor of the next page.
+func (cfg *apiConfig) handlerGetBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Users      []BlockedUser `json:"users"`
+		NextCursor string        `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	rows, err := cfg.db.ListBlockedUsers(r.Context(), database.ListBlockedUsersParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve users", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(rows) > int(page.Limit) {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		nextCursor = encodeCursor(last.BlockedAt, last.User.ID)
+	}
+
+	users := make([]BlockedUser, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, BlockedUser{
+			UserSummary: databaseUserToUserSummary(row.User),
+			BlockedAt:   row.BlockedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Users:      users,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerMuteUser handles muting a user.
+//
+// It expects a JSON payload in the request body with the field "user_id".
+// A mute works one way and the muted user isn't told: their chirps are left out of every chirp listing of the muting user,
+// but they may still follow, reply to and mention them. Muting is idempotent.
+// Returns a 204 No Content response if the user is muted, or an error response otherwise.
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if params.UserID == userID {
+		respondWithError(w, http.StatusBadRequest, "Users can't mute themselves", errors.New("self mute"))
+		return
+	}
+
+	_, err = cfg.db.GetUserByID(r.Context(), params.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+
+	err = cfg.db.MuteUser(r.Context(), database.MuteUserParams{
+		MuterID: userID,
+		MutedID: params.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mute user", err)
+		return
+	}
+
+	cfg.publishHiddenUsersChanged(r.Context(), userID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUnmuteUser handles removing the mute of the user in the path.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Unmuting is idempotent, unmuting a user who is not muted succeeds without changing anything.
+// Returns a 204 No Content response if the mute is removed, or an error response otherwise.
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	mutedIDString := r.PathValue("userID")
+	mutedID, err := uuid.Parse(mutedIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	err = cfg.db.UnmuteUser(r.Context(), database.UnmuteUserParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unmute user", err)
+		return
+	}
+
+	cfg.publishHiddenUsersChanged(r.Context(), userID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetMutedUsers handles the listing of the users the authenticated user muted, one page at a time.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The most recent mutes come first, the optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of muted users and the cursor of the next page.
+func (cfg *apiConfig) handlerGetMutedUsers(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Users      []MutedUser `json:"users"`
+		NextCursor string      `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	rows, err := cfg.db.ListMutedUsers(r.Context(), database.ListMutedUsersParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve users", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(rows) > int(page.Limit) {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		nextCursor = encodeCursor(last.MutedAt, last.User.ID)
+	}
+
+	users := make([]MutedUser, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, MutedUser{
+			UserSummary: databaseUserToUserSummary(row.User),
+			MutedAt:     row.MutedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Users:      users,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerBookmarkChirp handles bookmarking a chirp.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Bookmarks are private, only the user sees them. Bookmarking is idempotent,
+// bookmarking an already bookmarked chirp succeeds without changing anything.
+// Only published chirps can be bookmarked, and not those of a user who blocked the user or was blocked by them.
+// Returns a 204 No Content response if the chirp is bookmarked, or an error response otherwise.
+func (cfg *apiConfig) handlerBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpById(r.Context(), chirpID)
+	if err == nil && chirp.Status != chirpStatusPublished {
+		err = errors.New("the chirp is not published")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+
+	blocked, err := cfg.blockedBetween(r.Context(), chirp.UserID, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", errors.New("blocked"))
+		return
+	}
+
+	err = cfg.db.BookmarkChirp(r.Context(), database.BookmarkChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't bookmark chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUnbookmarkChirp handles removing the bookmark of a chirp.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Removing a bookmark is idempotent, it succeeds without changing anything for a chirp that is not bookmarked.
+// Returns a 204 No Content response if the bookmark is removed, or an error response otherwise.
+func (cfg *apiConfig) handlerUnbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	err = cfg.db.UnbookmarkChirp(r.Context(), database.UnbookmarkChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't remove bookmark", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetBookmarks handles the listing of the bookmarked chirps of the user, one page at a time.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The most recently bookmarked chirps come first, the optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of bookmarked chirps and the cursor of the next page.
+func (cfg *apiConfig) handlerGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	// the cursor holds the bookmark time, the order of this list
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	rows, err := cfg.db.ListBookmarks(r.Context(), database.ListBookmarksParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve bookmarks", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(rows) > int(page.Limit) {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		nextCursor = encodeCursor(last.BookmarkedAt, last.Chirp.ID)
+	}
+
+	chirps := make([]Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, databaseChirpToChirp(row.Chirp))
+	}
+	err = cfg.hydrateChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve bookmarks", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
@@ -1,25 +1,40 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"sort"
-	"strings"
+	"time"
 
 	"github.com/ArrayOfLilly/chirp/internal/auth"
 	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/textlength"
 	"github.com/google/uuid"
 )
 
 // handlerChirpsCreate handles the creation of a new chirp.
 //
 // It takes an http.ResponseWriter and an http.Request as parameters.
+// The optional in_reply_to field of the payload makes the chirp a reply to an existing chirp,
+// replying to a user who blocked the author or was blocked by them is forbidden.
+// The optional publish_at field (RFC 3339, within a year) schedules the chirp, it is published at that time
+// by runScheduledPublisher and is visible only to its author until then.
+// @handle mentions of existing users and #hashtags in the body are stored together with the chirp.
+// The payload is either JSON, or multipart/form-data with the same fields as form values plus up to four
+// "images" files (JPEG or PNG, 5 MB each) and an optional "alt_text" value per image.
+// The optional poll field ({"options": [...], "closes_at": ...}, or repeated "poll_options" and "poll_closes_at" form values)
+// attaches a poll of 2 to 4 options of up to 25 characters, open for 5 minutes to 7 days after the chirp is published.
+// The body and the poll options go through the content filter: a chirp using a word of a reject rule is refused with 400 Bad Request,
+// one using a word of a moderate rule is stored pending review and answered with 202 Accepted.
 // It returns no value, but writes the result of the creation (a chirp) to the http.ResponseWriter.
 func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body      string          `json:"body"`
+		InReplyTo *uuid.UUID      `json:"in_reply_to"`
+		PublishAt *time.Time      `json:"publish_at"`
+		Poll      *pollParameters `json:"poll"`
 	}
 
 	type response struct {
@@ -38,66 +53,236 @@ func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, r *http.Request
 	}
 
 	params := parameters{}
+	uploads := []attachmentUpload{}
+
+	if isMultipartRequest(r) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxChirpUploadSize)
+		err = r.ParseMultipartForm(maxUploadMemory)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Upload is too large", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Couldn't parse form", err)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		params.Body = r.FormValue("body")
+		inReplyToString := r.FormValue("in_reply_to")
+		if inReplyToString != "" {
+			inReplyTo, err := uuid.Parse(inReplyToString)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+				return
+			}
+			params.InReplyTo = &inReplyTo
+		}
+		publishAtString := r.FormValue("publish_at")
+		if publishAtString != "" {
+			publishAt, err := time.Parse(time.RFC3339, publishAtString)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid publish_at", err)
+				return
+			}
+			params.PublishAt = &publishAt
+		}
+		pollOptions := r.MultipartForm.Value["poll_options"]
+		if len(pollOptions) > 0 {
+			params.Poll = &pollParameters{Options: pollOptions}
+			pollClosesAtString := r.FormValue("poll_closes_at")
+			if pollClosesAtString != "" {
+				pollClosesAt, err := time.Parse(time.RFC3339, pollClosesAtString)
+				if err != nil {
+					respondWithError(w, http.StatusBadRequest, "Invalid poll_closes_at", err)
+					return
+				}
+				params.Poll.ClosesAt = &pollClosesAt
+			}
+		}
 
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&params)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
-		return
+		uploads, err = readAttachments(r.Context(), r.MultipartForm)
+		if err != nil {
+			respondWithError(w, attachmentErrorStatus(err), "Invalid image: "+err.Error(), err)
+			return
+		}
+	} else {
+		decoder := json.NewDecoder(r.Body)
+		err = decoder.Decode(&params)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+			return
+		}
 	}
 
-	badWordList := []string{"kerfuffle", "sharbert", "fornax"}
+	publishAt := sql.NullTime{}
+	if params.PublishAt != nil {
+		err = validatePublishAt(*params.PublishAt)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		publishAt = sql.NullTime{Time: params.PublishAt.UTC(), Valid: true}
+	}
 
-	cleanedBody := filterProphane(params.Body, badWordList)
-	validChirp, err := validateChirp(cleanedBody)
+	cleanedBody, status, err := cfg.filterChirp(params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	pollLabels := []string{}
+	if params.Poll != nil {
+		labels, pollStatus, err := cfg.filterPoll(*params.Poll, publishAt)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		if pollStatus == chirpStatusPendingReview {
+			status = chirpStatusPendingReview
+		}
+		pollLabels = labels
+	}
+	status = scheduledStatus(status, publishAt)
+	maxLength, err := cfg.chirpLengthLimit(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	validChirp, err := validateChirp(cleanedBody, maxLength)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
 	}
 	
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-        Body:   validChirp,
-		UserID: userID,
+	replyToID := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		replyTo, err := cfg.db.GetChirpById(r.Context(), *params.InReplyTo)
+		if err == nil && replyTo.Status != chirpStatusPublished {
+			err = errors.New("the chirp to reply to is not published")
+		}
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp to reply to", err)
+			return
+		}
+		blocked, err := cfg.blockedBetween(r.Context(), userID, replyTo.UserID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusForbidden, "Couldn't reply to this user", errors.New("blocked"))
+			return
+		}
+		replyToID = uuid.NullUUID{UUID: *params.InReplyTo, Valid: true}
+	}
+
+	// the images are stored before the transaction starts, and deleted again unless it commits
+	attachments, err := cfg.storeAttachments(r.Context(), uploads)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store images", err)
+		return
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			cfg.deleteBlobs(attachmentKeys(attachments))
+		}
+	}()
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.CreateChirp(r.Context(), database.CreateChirpParams{
+        Body:      validChirp,
+		UserID:    userID,
+		ReplyToID: replyToID,
+		Status:    status,
+		PublishAt: publishAt,
     })
     if err != nil {
         respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
         return
     }
 
-    respondWithJSON(w, http.StatusCreated, response{
-		Chirp: databaseChirpToChirp(chirp),
+	notifications, err := saveChirpEntities(r.Context(), qtx, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save mentions and hashtags", err)
+		return
+	}
+
+	for _, attachment := range attachments {
+		attachment.ChirpID = chirp.ID
+		err = qtx.CreateChirpAttachment(r.Context(), attachment)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't save images", err)
+			return
+		}
+	}
+
+	if params.Poll != nil {
+		err = qtx.CreatePoll(r.Context(), database.CreatePollParams{
+			ChirpID:  chirp.ID,
+			ClosesAt: params.Poll.ClosesAt.UTC(),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't create poll", err)
+			return
+		}
+		err = qtx.CreatePollOptions(r.Context(), database.CreatePollOptionsParams{
+			ChirpID: chirp.ID,
+			Labels:  pollLabels,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't create poll", err)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+	committed = true
+	cfg.publishChirps(r.Context(), []database.Chirp{chirp})
+	cfg.publishNotifications(r.Context(), notifications)
+
+	chirps := []Chirp{databaseChirpToChirp(chirp)}
+	err = cfg.hydrateChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	responseStatus := http.StatusCreated
+	if chirp.Status == chirpStatusPendingReview {
+		responseStatus = http.StatusAccepted
+	}
+    respondWithJSON(w, responseStatus, response{
+		Chirp: chirps[0],
 	})
 }
 
 // validateChirp validates the length of a chirp message.
 //
-// It takes a string message as a parameter.
+// It takes a string message and the maximum length for its author (see chirpLengthLimit) as parameters.
+// The length is counted in user-perceived characters, with links at a fixed weight, see textlength.Length.
 // Returns the validated message and an error if the message exceeds the maximum allowed length.
-func validateChirp(msg string) (string, error) {
-	const maxChirpLength = 140
-
-	if len(msg) > maxChirpLength {
+func validateChirp(msg string, maxLength int) (string, error) {
+	if textlength.Length(msg) > maxLength {
 		return "", errors.New("Chirp is too long")
 	}
 
 	return msg, nil
 }
 
-// filterProphane filters out profane words from a given string.
-//
-// It takes a string `s` and a slice of words to filter `wordsToFilter` as parameters.
-// It returns the filtered string with profane words replaced with "****".
-func filterProphane(s string, wordsToFilter []string) string {
-	words := strings.Split(s, " ")
-
-	for i := range words {
-		if contains(wordsToFilter, strings.ToLower(words[i])) {
-			words[i] = "****"
-		}
-	}
-	return strings.Join(words, " ")
-}
-
 // contains checks if a string slice contains a specific string element.
 //
 // It takes a string slice s and a string e as parameters.
@@ -111,61 +296,101 @@ func contains(s []string, e string) bool {
     return false
 }
 
-// handlerGetAllChirps handles the retrieval of all chirps.
+// handlerGetAllChirps handles the retrieval of chirps, one page at a time.
 //
 // It takes an http.ResponseWriter and an http.Request as parameters.
-// Returns a JSON response containing a list of Chirp objects.
+// Returns a JSON response containing a page of Chirp objects and the cursor of the next page.
 // If there's an optional author_id search query it lists the chirps by the userID of author
-// otherwise it lists all chirps by creation time in ascending orded
-// There's an optional sort querz string with asc and desc value for sorting
+// otherwise it lists all chirps by creation time in ascending order
+// There's an optional sort query string with asc and desc value for sorting
+// The optional limit and cursor query strings select the page, the cursor is the next_cursor of the previous page.
+// Chirps hidden from the viewer by a block or a mute are left out, like in every other chirp listing.
 func (cfg *apiConfig) handlerGetAllChirps(w http.ResponseWriter, r *http.Request) {
-	response := []Chirp{}
-
-	dbChirps, err := cfg.db.GetAllChirps(r.Context())
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
-		return
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
 	}
 
-	authorID := uuid.Nil
+	authorID := uuid.NullUUID{}
 	authorIDString := r.URL.Query().Get("author_id")
 	if authorIDString != "" {
-		authorID, err = uuid.Parse(authorIDString)
+		id, err := uuid.Parse(authorIDString)
 		if err != nil {
 			respondWithError(w, http.StatusBadRequest, "Invalid author ID", err)
 			return
 		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
 	}
 
-	sortDirection := "asc"
-	sortDirectionParam := r.URL.Query().Get("sort")
-	if sortDirectionParam == "desc" {
-		sortDirection = "desc"
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	viewerID := cfg.viewerID(r)
+	var dbChirps []database.Chirp
+	if r.URL.Query().Get("sort") == "desc" {
+		dbChirps, err = cfg.db.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+			ViewerID:        viewerID,
+			AuthorID:        authorID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			Limit:           page.fetchLimit(),
+		})
+	} else {
+		dbChirps, err = cfg.db.ListChirpsAsc(r.Context(), database.ListChirpsAscParams{
+			ViewerID:        viewerID,
+			AuthorID:        authorID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			Limit:           page.fetchLimit(),
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
 	}
 
-	for _, dbChirp := range dbChirps {
-		if authorID != uuid.Nil && dbChirp.UserID != authorID {
-			continue
-		}
-
-		response = append(response, databaseChirpToChirp(dbChirp))
+	chirps, nextCursor := chirpsPage(dbChirps, page.Limit)
+	err = cfg.hydrateChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
 	}
 
-	sort.Slice(response, func(i, j int) bool {
-		if sortDirection == "desc" {
-			return response[i].CreatedAt.After(response[j].CreatedAt)
-		}
-		return response[i].CreatedAt.Before(response[j].CreatedAt)
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
 	})
+}
+
+// chirpsPage converts a database result fetched with pageRequest.fetchLimit into a page of chirps.
+//
+// It takes the database chirps and the requested page size as parameters.
+// Returns at most limit chirps and the cursor of the next page, which is empty on the last page.
+func chirpsPage(dbChirps []database.Chirp, limit int32) ([]Chirp, string) {
+	nextCursor := ""
+	if len(dbChirps) > int(limit) {
+		dbChirps = dbChirps[:limit]
+		last := dbChirps[len(dbChirps)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
 
-	respondWithJSON(w, http.StatusOK, response)
+	chirps := make([]Chirp, 0, len(dbChirps))
+	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, databaseChirpToChirp(dbChirp))
+	}
+	return chirps, nextCursor
 }
-	
 
 // handlerGetChirpById handles the retrieval of a chirp by its ID.
 //
 // It takes an http.ResponseWriter and an http.Request as parameters.
 // Returns a JSON response containing a Chirp object by the pattern in the path.
+// A chirp that is pending review or rejected is found only by its author,
+// a chirp of a user who blocked the viewer or was blocked by them is not found.
 func (cfg *apiConfig) handlerGetChirpById(w http.ResponseWriter, r *http.Request) {	
 	
 	type response struct {
@@ -179,14 +404,34 @@ func (cfg *apiConfig) handlerGetChirpById(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	viewerID := cfg.viewerID(r)
 	chirp, err := cfg.db.GetChirpById(r.Context(), chirpID)
+	if err == nil && !chirpVisibleTo(chirp, viewerID) {
+		err = errors.New("the chirp is not published")
+	}
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
 		return
 	}
+	blocked, err := cfg.blockedBetween(r.Context(), chirp.UserID, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", errors.New("blocked"))
+		return
+	}
+
+	chirps := []Chirp{databaseChirpToChirp(chirp)}
+	err = cfg.hydrateChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
 
 	respondWithJSON(w, http.StatusOK, response{
-		Chirp: databaseChirpToChirp(chirp),
+		Chirp: chirps[0],
 	})
 }
 
@@ -225,11 +470,25 @@ func (cfg *apiConfig) handlerDeleteChirpById(w http.ResponseWriter, r *http.Requ
 		return
 	}
 	
+	attachments, err := cfg.db.GetAttachmentsForChirps(r.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
+		return
+	}
+
 	err = cfg.db.DeleteChirpById(r.Context(), chirpID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
 		return
 	}
+	cfg.publishChirpDeleted(r.Context(), chirp)
+
+	// the rows of the attachments are gone with the chirp, their files are not
+	keys := []string{}
+	for _, attachment := range attachments {
+		keys = append(keys, attachment.StorageKey, attachment.ThumbnailKey)
+	}
+	cfg.deleteBlobs(keys)
 
 	w.WriteHeader(http.StatusNoContent)
 }
\ No newline at end of file
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerUpdateChirpById handles the edit of a chirp by its ID.
+//
+// It expects a JSON payload in the request body with the field "body".
+// Only the author of the chirp may edit it, the new body goes through the same filtering and validation as a new chirp.
+// An edit using a word of a moderate rule takes the chirp back to pending review, answered with 202 Accepted,
+// and a rejected chirp can't be edited. A scheduled chirp stays scheduled, even once its time has come, until the publisher publishes it.
+// The replaced body is kept as a revision, in the same transaction as the update.
+// It responds with a JSON payload containing the updated chirp.
+func (cfg *apiConfig) handlerUpdateChirpById(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	type response struct {
+		Chirp
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	cleanedBody, status, err := cfg.filterChirp(params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	maxLength, err := cfg.chirpLengthLimit(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	validChirp, err := validateChirp(cleanedBody, maxLength)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// lock the row, so concurrent edits can't lose a revision
+	chirp, err := qtx.GetChirpByIdForUpdate(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp", err)
+		return
+	}
+
+	if userID != chirp.UserID {
+		respondWithError(w, http.StatusForbidden, "Editing is forbidden", fmt.Errorf("User is not the author of the chirp"))
+		return
+	}
+
+	if chirp.RepostKind.String == "rechirp" {
+		respondWithError(w, http.StatusBadRequest, "Rechirps can't be edited", fmt.Errorf("Chirp is a rechirp"))
+		return
+	}
+
+	if chirp.Status == chirpStatusRejected {
+		respondWithError(w, http.StatusBadRequest, "Rejected chirps can't be edited", fmt.Errorf("Chirp has been rejected"))
+		return
+	}
+
+	// a published chirp keeps its publish time, but isn't scheduled again
+	publishAt := chirp.PublishAt
+	if chirp.Status == chirpStatusPublished {
+		publishAt = sql.NullTime{}
+	}
+
+	// an unchanged body is not a revision
+	updatedChirp := chirp
+	notifications := []database.Notification{}
+	if chirp.Body != validChirp {
+		_, err = qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+			CreatedAt: chirp.UpdatedAt,
+			ChirpID:   chirp.ID,
+			Body:      chirp.Body,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't save revision", err)
+			return
+		}
+
+		updatedChirp, err = qtx.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+			ID:     chirp.ID,
+			Body:   validChirp,
+			Status: scheduledStatus(status, publishAt),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+			return
+		}
+
+		notifications, err = saveChirpEntities(r.Context(), qtx, updatedChirp)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't save mentions and hashtags", err)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+	// an edit that clears a chirp held back for review publishes it
+	if chirp.Status != chirpStatusPublished {
+		cfg.publishChirps(r.Context(), []database.Chirp{updatedChirp})
+	}
+	cfg.publishNotifications(r.Context(), notifications)
+
+	chirps := []Chirp{databaseChirpToChirp(updatedChirp)}
+	err = cfg.hydrateChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	responseStatus := http.StatusOK
+	if updatedChirp.Status == chirpStatusPendingReview {
+		responseStatus = http.StatusAccepted
+	}
+	respondWithJSON(w, responseStatus, response{
+		Chirp: chirps[0],
+	})
+}
+
+// handlerGetChirpRevisions handles the retrieval of the edit history of a chirp.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Returns a JSON response containing the previous bodies of the chirp, oldest first.
+// Like the chirp itself, the revisions of a chirp that is not published are found only by its author,
+// and those of a chirp by a user who blocked the viewer or was blocked by them aren't found.
+func (cfg *apiConfig) handlerGetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	chirp, err := cfg.db.GetChirpById(r.Context(), chirpID)
+	if err == nil && !chirpVisibleTo(chirp, viewerID) {
+		err = errors.New("the chirp is not published")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+
+	blocked, err := cfg.blockedBetween(r.Context(), chirp.UserID, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", errors.New("blocked"))
+		return
+	}
+
+	dbRevisions, err := cfg.db.GetChirpRevisions(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve revisions", err)
+		return
+	}
+
+	response := []ChirpRevision{}
+	for _, dbRevision := range dbRevisions {
+		response = append(response, databaseChirpRevisionToChirpRevision(dbRevision))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
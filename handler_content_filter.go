@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ArrayOfLilly/chirp/internal/contentfilter"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerGetFilterRules handles the listing of the content filter rules stored in the database.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Only admins may list the rules, the rules of the word list file are not included.
+// Returns a JSON response containing the rules, ordered by word.
+func (cfg *apiConfig) handlerGetFilterRules(w http.ResponseWriter, r *http.Request) {
+	err := cfg.validateAdminKey(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate API key", err)
+		return
+	}
+
+	dbRules, err := cfg.db.ListContentFilterRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve rules", err)
+		return
+	}
+
+	response := []FilterRule{}
+	for _, dbRule := range dbRules {
+		response = append(response, databaseFilterRuleToFilterRule(dbRule))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handlerSaveFilterRule handles adding a content filter rule, or changing the action of an existing one.
+//
+// It expects a JSON payload in the request body with the fields "word" and "action" ("mask", "moderate" or "reject").
+// Only admins may edit the rules. The word is stored normalized, so "K3rfuffle" and "kerfuffle" are the same rule.
+// The new rule applies to chirps written after the response, on the other instances of the server once they hear of it,
+// chirps already stored are not filtered again.
+// Returns a JSON response containing the saved rule.
+func (cfg *apiConfig) handlerSaveFilterRule(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Word   string `json:"word"`
+		Action string `json:"action"`
+	}
+
+	err := cfg.validateAdminKey(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate API key", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	rule := contentfilter.Rule{
+		Word:   params.Word,
+		Action: contentfilter.Action(params.Action),
+	}
+	err = contentfilter.ValidateRule(rule)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbRule, err := cfg.db.UpsertContentFilterRule(r.Context(), database.UpsertContentFilterRuleParams{
+		Word:   contentfilter.Normalize(rule.Word),
+		Action: string(rule.Action),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save rule", err)
+		return
+	}
+
+	err = cfg.contentFilterChanged(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload content filter", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, databaseFilterRuleToFilterRule(dbRule))
+}
+
+// handlerDeleteFilterRule handles the deletion of a content filter rule by its ID.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Only admins may edit the rules. A rule of the word list file for the same word applies again after the deletion.
+// Returns a 204 No Content response if the rule is deleted, or an error response otherwise.
+func (cfg *apiConfig) handlerDeleteFilterRule(w http.ResponseWriter, r *http.Request) {
+	err := cfg.validateAdminKey(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate API key", err)
+		return
+	}
+
+	ruleIDString := r.PathValue("ruleID")
+	ruleID, err := uuid.Parse(ruleIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid rule ID", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteContentFilterRule(r.Context(), ruleID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete rule", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find rule", errors.New("no rule with this ID"))
+		return
+	}
+
+	err = cfg.contentFilterChanged(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload content filter", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
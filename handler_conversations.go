@@ -0,0 +1,596 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/textlength"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxConversationParticipants counts the user who starts the conversation too
+	maxConversationParticipants = 10
+	// maxMessageLength is counted in user-perceived characters
+	maxMessageLength = 1000
+)
+
+// errMessageRejected is returned by filterMessage for a message using a word of a reject or moderate rule.
+var errMessageRejected = errors.New("Message contains a prohibited word")
+
+// filterMessage runs the body of a message through the content filter used for chirps.
+//
+// It takes the body as a parameter.
+// Messages are never held for review, a word of a moderate rule rejects the message like a word of a reject rule.
+// Returns the body with the words of mask rules masked, or an error if the message is empty, too long or rejected.
+func (cfg *apiConfig) filterMessage(body string) (string, error) {
+	length := textlength.Graphemes(body)
+	if length == 0 || length > maxMessageLength {
+		return "", fmt.Errorf("A message is 1 to %d characters long", maxMessageLength)
+	}
+	cleanedBody, status, err := cfg.filterChirp(body)
+	if err != nil || status == chirpStatusPendingReview {
+		return "", errMessageRejected
+	}
+	return cleanedBody, nil
+}
+
+// hydrateConversations converts conversations to the API type and fills in their participants
+// and the number of messages the user hasn't read yet.
+//
+// It takes a context, the ID of the user viewing the conversations and the conversations.
+// Returns the conversations in the same order, or an error if a query fails.
+func (cfg *apiConfig) hydrateConversations(ctx context.Context, userID uuid.UUID, dbConversations []database.Conversation) ([]Conversation, error) {
+	conversations := make([]Conversation, 0, len(dbConversations))
+	ids := make([]uuid.UUID, 0, len(dbConversations))
+	byID := make(map[uuid.UUID]int, len(dbConversations))
+	for i, dbConversation := range dbConversations {
+		conversations = append(conversations, databaseConversationToConversation(dbConversation))
+		ids = append(ids, dbConversation.ID)
+		byID[dbConversation.ID] = i
+	}
+	if len(ids) == 0 {
+		return conversations, nil
+	}
+
+	participants, err := cfg.db.GetConversationParticipants(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, participant := range participants {
+		i := byID[participant.ConversationID]
+		conversationParticipant := ConversationParticipant{
+			UserSummary: databaseUserToUserSummary(participant.User),
+		}
+		if participant.LastReadAt.Valid {
+			conversationParticipant.LastReadAt = &participant.LastReadAt.Time
+		}
+		conversations[i].Participants = append(conversations[i].Participants, conversationParticipant)
+	}
+
+	unread, err := cfg.db.CountUnreadMessages(ctx, database.CountUnreadMessagesParams{
+		UserID:          userID,
+		ConversationIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range unread {
+		conversations[byID[count.ConversationID]].UnreadCount = count.UnreadCount
+	}
+
+	return conversations, nil
+}
+
+// handlerCreateConversation handles starting a conversation with one or more users.
+//
+// It expects a JSON payload in the request body with the field "participant_ids", the users to talk to.
+// A conversation with a single other user is reused if the two users already have one, a group conversation is always new.
+// Users who blocked the authenticated user, or were blocked by them, can't be added.
+// Returns a JSON response containing the conversation, with 201 Created if it is new and 200 OK if it is reused.
+func (cfg *apiConfig) handlerCreateConversation(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ParticipantIDs []uuid.UUID `json:"participant_ids"`
+	}
+
+	type response struct {
+		Conversation
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	// the authenticated user is always a participant, listing them again changes nothing
+	otherIDs := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{userID: true}
+	for _, participantID := range params.ParticipantIDs {
+		if seen[participantID] {
+			continue
+		}
+		seen[participantID] = true
+		otherIDs = append(otherIDs, participantID)
+	}
+	if len(otherIDs) == 0 || len(otherIDs)+1 > maxConversationParticipants {
+		err = fmt.Errorf("A conversation has 2 to %d participants", maxConversationParticipants)
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	blockedIDs, err := cfg.db.GetBlockedUserIDs(r.Context(), database.GetBlockedUserIDsParams{
+		UserIds: otherIDs,
+		UserID:  userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", err)
+		return
+	}
+	if len(blockedIDs) > 0 {
+		respondWithError(w, http.StatusForbidden, "Can't start a conversation with this user", errors.New("blocked"))
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	isGroup := len(otherIDs) > 1
+	if !isGroup {
+		err = qtx.LockDirectConversation(r.Context(), database.LockDirectConversationParams{
+			UserID:      userID,
+			OtherUserID: otherIDs[0],
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", err)
+			return
+		}
+		existing, err := qtx.FindDirectConversation(r.Context(), database.FindDirectConversationParams{
+			UserID:      userID,
+			OtherUserID: otherIDs[0],
+		})
+		if err == nil {
+			conversations, err := cfg.hydrateConversations(r.Context(), userID, []database.Conversation{existing})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversation", err)
+				return
+			}
+			respondWithJSON(w, http.StatusOK, response{
+				Conversation: conversations[0],
+			})
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", err)
+			return
+		}
+	}
+
+	conversation, err := qtx.CreateConversation(r.Context(), isGroup)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", err)
+		return
+	}
+
+	err = qtx.AddConversationParticipants(r.Context(), database.AddConversationParticipantsParams{
+		ConversationID: conversation.ID,
+		UserIds:        append([]uuid.UUID{userID}, otherIDs...),
+	})
+	if foreignKeyViolation(err) != "" {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", err)
+		return
+	}
+
+	conversations, err := cfg.hydrateConversations(r.Context(), userID, []database.Conversation{conversation})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversation", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, response{
+		Conversation: conversations[0],
+	})
+}
+
+// handlerGetConversations handles the listing of the conversations of the user, one page at a time.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The conversations with the latest messages come first, the optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of conversations and the cursor of the next page.
+func (cfg *apiConfig) handlerGetConversations(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Conversations []Conversation `json:"conversations"`
+		NextCursor    string         `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	// the cursor holds the time of the latest activity, the order of this list
+	cursorUpdatedAt, cursorID := page.cursorParams()
+
+	dbConversations, err := cfg.db.ListConversations(r.Context(), database.ListConversationsParams{
+		UserID:          userID,
+		CursorUpdatedAt: cursorUpdatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversations", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(dbConversations) > int(page.Limit) {
+		dbConversations = dbConversations[:page.Limit]
+		last := dbConversations[len(dbConversations)-1]
+		nextCursor = encodeCursor(last.UpdatedAt, last.ID)
+	}
+
+	conversations, err := cfg.hydrateConversations(r.Context(), userID, dbConversations)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversations", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Conversations: conversations,
+		NextCursor:    nextCursor,
+	})
+}
+
+// handlerGetConversation handles retrieving a conversation by its ID.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Only participants may see a conversation, to everyone else it doesn't exist.
+// Returns a JSON response containing the conversation with its participants and their read receipts.
+func (cfg *apiConfig) handlerGetConversation(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Conversation
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	conversationIDString := r.PathValue("conversationID")
+	conversationID, err := uuid.Parse(conversationIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid conversation ID", err)
+		return
+	}
+
+	conversation, err := cfg.db.GetConversationForUser(r.Context(), database.GetConversationForUserParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find conversation", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversation", err)
+		return
+	}
+
+	conversations, err := cfg.hydrateConversations(r.Context(), userID, []database.Conversation{conversation})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversation", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Conversation: conversations[0],
+	})
+}
+
+// handlerCreateMessage handles sending a message to a conversation.
+//
+// It expects a JSON payload in the request body with the field "body", which goes through the same content filter as chirps.
+// Only participants may send messages, and not while a block stands between the sender and any other participant.
+// Sending a message marks the conversation read for the sender.
+// Returns a JSON response containing the message.
+func (cfg *apiConfig) handlerCreateMessage(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	type response struct {
+		Message
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	conversationIDString := r.PathValue("conversationID")
+	conversationID, err := uuid.Parse(conversationIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid conversation ID", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	cleanedBody, err := cfg.filterMessage(params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	_, err = cfg.db.GetConversationForUser(r.Context(), database.GetConversationForUserParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find conversation", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+
+	participants, err := cfg.db.GetConversationParticipants(r.Context(), []uuid.UUID{conversationID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+	participantIDs := make([]uuid.UUID, 0, len(participants))
+	for _, participant := range participants {
+		participantIDs = append(participantIDs, participant.User.ID)
+	}
+	blockedIDs, err := cfg.db.GetBlockedUserIDs(r.Context(), database.GetBlockedUserIDsParams{
+		UserIds: participantIDs,
+		UserID:  userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+	if len(blockedIDs) > 0 {
+		respondWithError(w, http.StatusForbidden, "Can't send messages to this conversation", errors.New("blocked"))
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	message, err := qtx.CreateMessage(r.Context(), database.CreateMessageParams{
+		ConversationID: conversationID,
+		SenderID:       userID,
+		Body:           cleanedBody,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+
+	err = qtx.TouchConversation(r.Context(), conversationID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+
+	// NOW() is the time of the transaction, the message itself is read
+	err = qtx.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, response{
+		Message: databaseMessageToMessage(message),
+	})
+}
+
+// handlerGetMessages handles the listing of the messages of a conversation, one page at a time.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Only participants may read a conversation. The newest messages come first, the optional limit and cursor query strings select the page.
+// Reading messages doesn't move the read receipt, see handlerMarkConversationRead.
+// Returns a JSON response containing a page of messages and the cursor of the next page.
+func (cfg *apiConfig) handlerGetMessages(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Messages   []Message `json:"messages"`
+		NextCursor string    `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	conversationIDString := r.PathValue("conversationID")
+	conversationID, err := uuid.Parse(conversationIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid conversation ID", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	_, err = cfg.db.GetConversationForUser(r.Context(), database.GetConversationForUserParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find conversation", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve messages", err)
+		return
+	}
+
+	dbMessages, err := cfg.db.ListMessages(r.Context(), database.ListMessagesParams{
+		ConversationID:  conversationID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve messages", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(dbMessages) > int(page.Limit) {
+		dbMessages = dbMessages[:page.Limit]
+		last := dbMessages[len(dbMessages)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	messages := make([]Message, 0, len(dbMessages))
+	for _, dbMessage := range dbMessages {
+		messages = append(messages, databaseMessageToMessage(dbMessage))
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Messages:   messages,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerMarkConversationRead handles moving the read receipt of the user to the present.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Every message sent so far counts as read, the receipt never moves back.
+// Returns a 204 No Content response if the receipt is updated, or an error response otherwise.
+func (cfg *apiConfig) handlerMarkConversationRead(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	conversationIDString := r.PathValue("conversationID")
+	conversationID, err := uuid.Parse(conversationIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid conversation ID", err)
+		return
+	}
+
+	_, err = cfg.db.GetConversationForUser(r.Context(), database.GetConversationForUserParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find conversation", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark conversation read", err)
+		return
+	}
+
+	err = cfg.db.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark conversation read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
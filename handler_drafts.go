@@ -0,0 +1,462 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// maxDraftLength bounds the body of a draft in characters. Drafts are checked like chirps only when they are
+// published, so a draft may be longer than a chirp while it is being worked on, but not without limit.
+const maxDraftLength = 5000
+
+// handlerCreateDraft handles saving a new draft.
+//
+// It expects a JSON payload in the request body with the field "body" and the optional field "in_reply_to".
+// The chirp to reply to must be published, and by a user who didn't block the user and wasn't blocked by them.
+// The body is not filtered or checked against the chirp length limit until the draft is published.
+// Returns a JSON response containing the draft.
+func (cfg *apiConfig) handlerCreateDraft(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body      string     `json:"body"`
+		InReplyTo *uuid.UUID `json:"in_reply_to"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	err = validateDraft(params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	replyToID := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		replyTo, err := cfg.db.GetChirpById(r.Context(), *params.InReplyTo)
+		if err == nil && replyTo.Status != chirpStatusPublished {
+			err = errors.New("the chirp to reply to is not published")
+		}
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp to reply to", err)
+			return
+		}
+		blocked, err := cfg.blockedBetween(r.Context(), userID, replyTo.UserID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusForbidden, "Couldn't reply to this user", errors.New("blocked"))
+			return
+		}
+		replyToID = uuid.NullUUID{UUID: *params.InReplyTo, Valid: true}
+	}
+
+	draft, err := cfg.db.CreateDraft(r.Context(), database.CreateDraftParams{
+		UserID:    userID,
+		Body:      params.Body,
+		ReplyToID: replyToID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save draft", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, databaseDraftToDraft(draft))
+}
+
+// handlerGetDrafts handles the listing of the drafts of the user, one page at a time.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The newest drafts come first, the optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of drafts and the cursor of the next page.
+func (cfg *apiConfig) handlerGetDrafts(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Drafts     []Draft `json:"drafts"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	dbDrafts, err := cfg.db.ListDrafts(r.Context(), database.ListDraftsParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve drafts", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(dbDrafts) > int(page.Limit) {
+		dbDrafts = dbDrafts[:page.Limit]
+		last := dbDrafts[len(dbDrafts)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	drafts := make([]Draft, 0, len(dbDrafts))
+	for _, dbDraft := range dbDrafts {
+		drafts = append(drafts, databaseDraftToDraft(dbDraft))
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Drafts:     drafts,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerGetDraft handles the retrieval of a draft of the user by its ID.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Returns a JSON response containing the draft, drafts of other users are not found.
+func (cfg *apiConfig) handlerGetDraft(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	draftIDString := r.PathValue("draftID")
+	draftID, err := uuid.Parse(draftIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return
+	}
+
+	draft, err := cfg.db.GetDraft(r.Context(), database.GetDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find draft", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get draft", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseDraftToDraft(draft))
+}
+
+// handlerUpdateDraft handles replacing the content of a draft of the user.
+//
+// It expects a JSON payload in the request body with the field "body" and the optional field "in_reply_to",
+// which replace those of the draft.
+// The chirp to reply to must be published, and by a user who didn't block the user and wasn't blocked by them.
+// Returns a JSON response containing the updated draft.
+func (cfg *apiConfig) handlerUpdateDraft(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body      string     `json:"body"`
+		InReplyTo *uuid.UUID `json:"in_reply_to"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	draftIDString := r.PathValue("draftID")
+	draftID, err := uuid.Parse(draftIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	err = validateDraft(params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	replyToID := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		replyTo, err := cfg.db.GetChirpById(r.Context(), *params.InReplyTo)
+		if err == nil && replyTo.Status != chirpStatusPublished {
+			err = errors.New("the chirp to reply to is not published")
+		}
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp to reply to", err)
+			return
+		}
+		blocked, err := cfg.blockedBetween(r.Context(), userID, replyTo.UserID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusForbidden, "Couldn't reply to this user", errors.New("blocked"))
+			return
+		}
+		replyToID = uuid.NullUUID{UUID: *params.InReplyTo, Valid: true}
+	}
+
+	draft, err := cfg.db.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		ID:        draftID,
+		UserID:    userID,
+		Body:      params.Body,
+		ReplyToID: replyToID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find draft", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save draft", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseDraftToDraft(draft))
+}
+
+// handlerDeleteDraft handles the deletion of a draft of the user.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Returns a 204 No Content response if the draft is deleted, or an error response otherwise.
+func (cfg *apiConfig) handlerDeleteDraft(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	draftIDString := r.PathValue("draftID")
+	draftID, err := uuid.Parse(draftIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete draft", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find draft", errors.New("no draft with this ID"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerPublishDraft handles turning a draft of the user into a chirp.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The body of the draft goes through the same filtering and validation as a new chirp. The chirp is created
+// and the draft deleted in one transaction, so a draft is published once even if the request is repeated.
+// Returns a JSON response containing the chirp, with 201 Created, or 202 Accepted if it is held back for moderation.
+func (cfg *apiConfig) handlerPublishDraft(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirp
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	draftIDString := r.PathValue("draftID")
+	draftID, err := uuid.Parse(draftIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return
+	}
+
+	maxLength, err := cfg.chirpLengthLimit(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// lock the row, so concurrent requests can't publish the draft twice
+	draft, err := qtx.GetDraftForUpdate(r.Context(), database.GetDraftForUpdateParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find draft", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get draft", err)
+		return
+	}
+
+	cleanedBody, status, err := cfg.filterChirp(draft.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	validChirp, err := validateChirp(cleanedBody, maxLength)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if draft.ReplyToID.Valid {
+		replyTo, err := qtx.GetChirpById(r.Context(), draft.ReplyToID.UUID)
+		if err == nil && replyTo.Status != chirpStatusPublished {
+			err = errors.New("the chirp to reply to is not published")
+		}
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp to reply to", err)
+			return
+		}
+		blocked, err := cfg.blockedBetween(r.Context(), userID, replyTo.UserID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusForbidden, "Couldn't reply to this user", errors.New("blocked"))
+			return
+		}
+	}
+
+	chirp, err := qtx.CreateChirp(r.Context(), database.CreateChirpParams{
+		Body:      validChirp,
+		UserID:    userID,
+		ReplyToID: draft.ReplyToID,
+		Status:    status,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+
+	notifications, err := saveChirpEntities(r.Context(), qtx, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save mentions and hashtags", err)
+		return
+	}
+
+	_, err = qtx.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     draft.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete draft", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+	cfg.publishChirps(r.Context(), []database.Chirp{chirp})
+	cfg.publishNotifications(r.Context(), notifications)
+
+	chirps := []Chirp{databaseChirpToChirp(chirp)}
+	err = cfg.hydrateChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	responseStatus := http.StatusCreated
+	if chirp.Status == chirpStatusPendingReview {
+		responseStatus = http.StatusAccepted
+	}
+	respondWithJSON(w, responseStatus, response{
+		Chirp: chirps[0],
+	})
+}
+
+// validateDraft validates the length of the body of a draft.
+//
+// It takes the body as a parameter.
+// Only the size is checked, the content filter and the chirp length limit apply when the draft is published.
+// Returns an error if the body exceeds maxDraftLength.
+func validateDraft(body string) error {
+	if utf8.RuneCountInString(body) > maxDraftLength {
+		return fmt.Errorf("Draft is longer than %d characters", maxDraftLength)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// Follow is a user in a followers or following list, with the time the follow started.
+type Follow struct {
+	UserSummary
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// handlerFollowUser handles following a user.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Following is idempotent, following an already followed user succeeds without changing anything.
+// Following a user who blocked the user or was blocked by them is forbidden. The followed user is notified of the first follow only.
+// Returns a 204 No Content response if the user is followed, or an error response otherwise.
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	followeeIDString := r.PathValue("userID")
+	followeeID, err := uuid.Parse(followeeIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	if followeeID == userID {
+		respondWithError(w, http.StatusBadRequest, "Users can't follow themselves", errors.New("self follow"))
+		return
+	}
+
+	_, err = cfg.db.GetUserByID(r.Context(), followeeID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+
+	blocked, err := cfg.blockedBetween(r.Context(), userID, followeeID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "Couldn't follow this user", errors.New("blocked"))
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't follow user", err)
+		return
+	}
+
+	notifications, err := notify(r.Context(), qtx, notificationTypeFollow, userID, uuid.Nil, uuid.Nil, []uuid.UUID{followeeID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't follow user", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't follow user", err)
+		return
+	}
+	cfg.publishNotifications(r.Context(), notifications)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUnfollowUser handles unfollowing a user.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Unfollowing is idempotent, unfollowing a user who is not followed succeeds without changing anything.
+// Returns a 204 No Content response if the user is unfollowed, or an error response otherwise.
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	followeeIDString := r.PathValue("userID")
+	followeeID, err := uuid.Parse(followeeIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	err = cfg.db.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unfollow user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetFollowers handles the retrieval of the followers of a user.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of followers, most recent first, and the cursor of the next page.
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	cfg.respondWithFollowList(w, r, cfg.listFollowers)
+}
+
+// handlerGetFollowing handles the retrieval of the users a user follows.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of followed users, most recent first, and the cursor of the next page.
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	cfg.respondWithFollowList(w, r, cfg.listFollowing)
+}
+
+// followLister loads one page of a follow list of a user.
+type followLister func(r *http.Request, userID uuid.UUID, page pageRequest) ([]Follow, error)
+
+// respondWithFollowList writes a page of a follow list of the user in the path to the client.
+//
+// It takes the http.ResponseWriter, the http.Request and the function loading the list.
+func (cfg *apiConfig) respondWithFollowList(w http.ResponseWriter, r *http.Request, list followLister) {
+	type response struct {
+		Users      []Follow `json:"users"`
+		NextCursor string   `json:"next_cursor,omitempty"`
+	}
+
+	userIDString := r.PathValue("userID")
+	userID, err := uuid.Parse(userIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	_, err = cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+
+	follows, err := list(r, userID, page)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve users", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(follows) > int(page.Limit) {
+		follows = follows[:page.Limit]
+		last := follows[len(follows)-1]
+		nextCursor = encodeCursor(last.FollowedAt, last.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Users:      follows,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) listFollowers(r *http.Request, userID uuid.UUID, page pageRequest) ([]Follow, error) {
+	cursorCreatedAt, cursorID := page.cursorParams()
+	rows, err := cfg.db.ListFollowers(r.Context(), database.ListFollowersParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	follows := make([]Follow, 0, len(rows))
+	for _, row := range rows {
+		follows = append(follows, Follow{
+			UserSummary: databaseUserToUserSummary(row.User),
+			FollowedAt:  row.FollowedAt,
+		})
+	}
+	return follows, nil
+}
+
+func (cfg *apiConfig) listFollowing(r *http.Request, userID uuid.UUID, page pageRequest) ([]Follow, error) {
+	cursorCreatedAt, cursorID := page.cursorParams()
+	rows, err := cfg.db.ListFollowing(r.Context(), database.ListFollowingParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	follows := make([]Follow, 0, len(rows))
+	for _, row := range rows {
+		follows = append(follows, Follow{
+			UserSummary: databaseUserToUserSummary(row.User),
+			FollowedAt:  row.FollowedAt,
+		})
+	}
+	return follows, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerLikeChirp handles liking a chirp.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Liking is idempotent, liking an already liked chirp succeeds without changing anything.
+// Only published chirps can be liked, and not those of a user who blocked the user or was blocked by them.
+// The author is notified of the first like only.
+// Returns a 204 No Content response if the chirp is liked, or an error response otherwise.
+func (cfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpById(r.Context(), chirpID)
+	if err == nil && chirp.Status != chirpStatusPublished {
+		err = errors.New("the chirp is not published")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+
+	blocked, err := cfg.blockedBetween(r.Context(), chirp.UserID, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", errors.New("blocked"))
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.LikeChirp(r.Context(), database.LikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't like chirp", err)
+		return
+	}
+
+	notifications, err := notify(r.Context(), qtx, notificationTypeLike, userID, chirpID, chirpID, []uuid.UUID{chirp.UserID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't like chirp", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't like chirp", err)
+		return
+	}
+	cfg.publishNotifications(r.Context(), notifications)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUnlikeChirp handles removing the like of a chirp.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Unliking is idempotent, unliking a chirp that is not liked succeeds without changing anything.
+// Returns a 204 No Content response if the like is removed, or an error response otherwise.
+func (cfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	err = cfg.db.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unlike chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ArrayOfLilly/chirp/internal/media"
+	"github.com/ArrayOfLilly/chirp/internal/textlength"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxChirpLength is the longest chirp in user-perceived characters, see validateChirp
+	maxChirpLength = 140
+	// maxChirpyRedChirpLength is the longest chirp of a Chirpy Red member
+	maxChirpyRedChirpLength = 280
+)
+
+// chirpLengthLimit returns the maximum length of the chirps of a user.
+//
+// It takes a context and the ID of the user, uuid.Nil for an anonymous reader.
+// Returns the limit of the tier of the user, or an error if the user can't be loaded.
+func (cfg *apiConfig) chirpLengthLimit(ctx context.Context, userID uuid.UUID) (int, error) {
+	if userID == uuid.Nil {
+		return maxChirpLength, nil
+	}
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if user.IsChirpyRed {
+		return maxChirpyRedChirpLength, nil
+	}
+	return maxChirpLength, nil
+}
+
+// handlerGetLimits handles the retrieval of the limits a new chirp has to fit, so clients can show a counter.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// With a valid bearer JWT max_chirp_length is the limit of the tier of the user, otherwise the default limit.
+// Returns a JSON response containing the limits.
+func (cfg *apiConfig) handlerGetLimits(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		MaxChirpLength          int `json:"max_chirp_length"`
+		MaxChirpyRedChirpLength int `json:"max_chirpy_red_chirp_length"`
+		URLWeight               int `json:"url_weight"`
+		MaxAttachments          int `json:"max_attachments"`
+		MaxAttachmentSize       int `json:"max_attachment_size"`
+		MaxAltTextLength        int `json:"max_alt_text_length"`
+	}
+
+	maxLength, err := cfg.chirpLengthLimit(r.Context(), cfg.viewerID(r))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		MaxChirpLength:          maxLength,
+		MaxChirpyRedChirpLength: maxChirpyRedChirpLength,
+		URLWeight:               textlength.URLWeight,
+		MaxAttachments:          maxAttachments,
+		MaxAttachmentSize:       media.MaxFileSize,
+		MaxAltTextLength:        maxAltTextLength,
+	})
+}
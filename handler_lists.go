@@ -0,0 +1,541 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/textlength"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxListNameLength and maxListDescriptionLength are counted in user-perceived characters
+	maxListNameLength        = 25
+	maxListDescriptionLength = 100
+)
+
+// ListMember is a member of a list together with the time they were added.
+type ListMember struct {
+	UserSummary
+	AddedAt time.Time `json:"added_at"`
+}
+
+// validateList checks the name and description of a list.
+//
+// It takes the name and the description as parameters.
+// Returns an error if the name is empty or either of them is too long.
+func validateList(name, description string) error {
+	length := textlength.Graphemes(name)
+	if length == 0 || length > maxListNameLength {
+		return fmt.Errorf("A list name is 1 to %d characters long", maxListNameLength)
+	}
+	if textlength.Graphemes(description) > maxListDescriptionLength {
+		return fmt.Errorf("A list description is at most %d characters long", maxListDescriptionLength)
+	}
+	return nil
+}
+
+// listVisibleTo reports whether a user may see a list: everyone sees public lists,
+// only the owner sees a private list.
+func listVisibleTo(list database.List, viewerID uuid.UUID) bool {
+	return !list.IsPrivate || list.UserID == viewerID
+}
+
+// handlerCreateList handles the creation of a new list.
+//
+// It expects a JSON payload in the request body with the field "name" and the optional fields
+// "description" and "private". The list starts without members.
+// Returns a JSON response containing the list.
+func (cfg *apiConfig) handlerCreateList(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Private     bool   `json:"private"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	err = validateList(params.Name, params.Description)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	list, err := cfg.db.CreateList(r.Context(), database.CreateListParams{
+		UserID:      userID,
+		Name:        params.Name,
+		Description: params.Description,
+		IsPrivate:   params.Private,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create list", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, databaseListToList(list))
+}
+
+// handlerGetUserLists handles the listing of the lists of a user, one page at a time.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The private lists are included only when the user asks for their own lists with a valid bearer JWT.
+// The newest lists come first, the optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of lists and the cursor of the next page.
+func (cfg *apiConfig) handlerGetUserLists(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Lists      []List `json:"lists"`
+		NextCursor string `json:"next_cursor,omitempty"`
+	}
+
+	userIDString := r.PathValue("userID")
+	userID, err := uuid.Parse(userIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	_, err = cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+
+	dbLists, err := cfg.db.ListListsForUser(r.Context(), database.ListListsForUserParams{
+		UserID:          userID,
+		IncludePrivate:  cfg.viewerID(r) == userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve lists", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(dbLists) > int(page.Limit) {
+		dbLists = dbLists[:page.Limit]
+		last := dbLists[len(dbLists)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	lists := make([]List, 0, len(dbLists))
+	for _, dbList := range dbLists {
+		lists = append(lists, databaseListToList(dbList))
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Lists:      lists,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerGetList handles the retrieval of a list by its ID.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// A private list is found only by its owner.
+// Returns a JSON response containing the list.
+func (cfg *apiConfig) handlerGetList(w http.ResponseWriter, r *http.Request) {
+	listIDString := r.PathValue("listID")
+	listID, err := uuid.Parse(listIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID", err)
+		return
+	}
+
+	list, err := cfg.db.GetList(r.Context(), listID)
+	if err == nil && !listVisibleTo(list, cfg.viewerID(r)) {
+		err = errors.New("the list is private")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find list", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseListToList(list))
+}
+
+// handlerUpdateList handles changing the name, description and visibility of a list.
+//
+// It expects a JSON payload in the request body with the same fields as handlerCreateList, all of them are replaced.
+// Only the owner may update a list.
+// Returns a JSON response containing the updated list.
+func (cfg *apiConfig) handlerUpdateList(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Private     bool   `json:"private"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	listIDString := r.PathValue("listID")
+	listID, err := uuid.Parse(listIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	err = validateList(params.Name, params.Description)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	list, err := cfg.db.UpdateList(r.Context(), database.UpdateListParams{
+		ID:          listID,
+		UserID:      userID,
+		Name:        params.Name,
+		Description: params.Description,
+		IsPrivate:   params.Private,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find list", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update list", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseListToList(list))
+}
+
+// handlerDeleteList handles the deletion of a list by its ID.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Only the owner may delete a list, the members stay untouched.
+// Returns a 204 No Content response if the list is deleted, or an error response otherwise.
+func (cfg *apiConfig) handlerDeleteList(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	listIDString := r.PathValue("listID")
+	listID, err := uuid.Parse(listIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteList(r.Context(), database.DeleteListParams{
+		ID:     listID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete list", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find list", errors.New("no list with this ID"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerAddListMember handles adding a user to a list.
+//
+// It expects a JSON payload in the request body with the field "user_id".
+// Only the owner may add members. Adding is idempotent, adding a member again succeeds without changing anything.
+// The added user is not notified, and can't see a private list they are a member of.
+// Returns a 204 No Content response if the user is added, or an error response otherwise.
+func (cfg *apiConfig) handlerAddListMember(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	listIDString := r.PathValue("listID")
+	listID, err := uuid.Parse(listIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	list, err := cfg.db.GetList(r.Context(), listID)
+	if err == nil && !listVisibleTo(list, userID) {
+		err = errors.New("the list is private")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find list", err)
+		return
+	}
+	if list.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Only the owner may edit a list", errors.New("user is not the owner of the list"))
+		return
+	}
+
+	err = cfg.db.AddListMember(r.Context(), database.AddListMemberParams{
+		ListID: listID,
+		UserID: params.UserID,
+	})
+	if foreignKeyViolation(err) != "" {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't add member", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerRemoveListMember handles removing the user in the path from a list.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Only the owner may remove members. Removing is idempotent, removing a user who is not a member succeeds without changing anything.
+// Returns a 204 No Content response if the user is removed, or an error response otherwise.
+func (cfg *apiConfig) handlerRemoveListMember(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	listIDString := r.PathValue("listID")
+	listID, err := uuid.Parse(listIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID", err)
+		return
+	}
+	memberIDString := r.PathValue("userID")
+	memberID, err := uuid.Parse(memberIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	list, err := cfg.db.GetList(r.Context(), listID)
+	if err == nil && !listVisibleTo(list, userID) {
+		err = errors.New("the list is private")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find list", err)
+		return
+	}
+	if list.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Only the owner may edit a list", errors.New("user is not the owner of the list"))
+		return
+	}
+
+	err = cfg.db.RemoveListMember(r.Context(), database.RemoveListMemberParams{
+		ListID: listID,
+		UserID: memberID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't remove member", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetListMembers handles the listing of the members of a list, one page at a time.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The members of a private list are listed only for its owner.
+// The most recently added members come first, the optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of members and the cursor of the next page.
+func (cfg *apiConfig) handlerGetListMembers(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Users      []ListMember `json:"users"`
+		NextCursor string       `json:"next_cursor,omitempty"`
+	}
+
+	listIDString := r.PathValue("listID")
+	listID, err := uuid.Parse(listIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	list, err := cfg.db.GetList(r.Context(), listID)
+	if err == nil && !listVisibleTo(list, cfg.viewerID(r)) {
+		err = errors.New("the list is private")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find list", err)
+		return
+	}
+
+	rows, err := cfg.db.ListListMembers(r.Context(), database.ListListMembersParams{
+		ListID:          listID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve members", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(rows) > int(page.Limit) {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		nextCursor = encodeCursor(last.AddedAt, last.User.ID)
+	}
+
+	members := make([]ListMember, 0, len(rows))
+	for _, row := range rows {
+		members = append(members, ListMember{
+			UserSummary: databaseUserToUserSummary(row.User),
+			AddedAt:     row.AddedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Users:      members,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerGetListChirps handles the retrieval of the timeline of a list, one page at a time.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The timeline holds the chirps of the members of the list, the timeline of a private list is found only by its owner.
+// The optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of chirps, newest first, and the cursor of the next page.
+func (cfg *apiConfig) handlerGetListChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	listIDString := r.PathValue("listID")
+	listID, err := uuid.Parse(listIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	viewerID := cfg.viewerID(r)
+	list, err := cfg.db.GetList(r.Context(), listID)
+	if err == nil && !listVisibleTo(list, viewerID) {
+		err = errors.New("the list is private")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find list", err)
+		return
+	}
+
+	dbChirps, err := cfg.db.ListListTimeline(r.Context(), database.ListListTimelineParams{
+		ListID:          listID,
+		ViewerID:        viewerID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve timeline", err)
+		return
+	}
+
+	chirps, nextCursor := chirpsPage(dbChirps, page.Limit)
+	err = cfg.hydrateChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve timeline", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+)
+
+// handlerGetMentions handles the retrieval of the chirps that mention the authenticated user.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of chirps, newest first, and the cursor of the next page.
+func (cfg *apiConfig) handlerGetMentions(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	dbChirps, err := cfg.db.ListMentions(r.Context(), database.ListMentionsParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve mentions", err)
+		return
+	}
+
+	chirps, nextCursor := chirpsPage(dbChirps, page.Limit)
+	err = cfg.hydrateChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve mentions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
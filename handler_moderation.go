@@ -0,0 +1,189 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerGetModerationQueue handles the listing of the chirps held back for moderation, one page at a time.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Only admins may list the queue. The oldest chirps come first, the optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of chirps pending review and the cursor of the next page.
+func (cfg *apiConfig) handlerGetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	err := cfg.validateAdminKey(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate API key", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	dbChirps, err := cfg.db.ListChirpsForModeration(r.Context(), database.ListChirpsForModerationParams{
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	chirps, nextCursor := chirpsPage(dbChirps, page.Limit)
+	err = cfg.hydrateChirps(r.Context(), uuid.Nil, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerApproveChirp handles publishing a chirp that is pending review.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Only admins may moderate chirps. The mentions and hashtags of the chirp are stored when it is published,
+// a chirp scheduled for a time that hasn't come yet is scheduled again instead.
+// Returns a JSON response containing the published chirp.
+func (cfg *apiConfig) handlerApproveChirp(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirp
+	}
+
+	err := cfg.validateAdminKey(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate API key", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// lock the row, the author may be editing it
+	chirp, err := qtx.GetChirpByIdForUpdate(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp pending review", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't approve chirp", err)
+		return
+	}
+	if chirp.Status != chirpStatusPendingReview {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp pending review", errors.New("the chirp is not pending review"))
+		return
+	}
+
+	chirp, err = qtx.UpdateChirpStatus(r.Context(), database.UpdateChirpStatusParams{
+		Status:     scheduledStatus(chirpStatusPublished, chirp.PublishAt),
+		ID:         chirpID,
+		FromStatus: chirpStatusPendingReview,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't approve chirp", err)
+		return
+	}
+
+	notifications, err := saveChirpEntities(r.Context(), qtx, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save mentions and hashtags", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't approve chirp", err)
+		return
+	}
+	cfg.publishChirps(r.Context(), []database.Chirp{chirp})
+	cfg.publishNotifications(r.Context(), notifications)
+
+	chirps := []Chirp{databaseChirpToChirp(chirp)}
+	err = cfg.hydrateChirps(r.Context(), uuid.Nil, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirp: chirps[0],
+	})
+}
+
+// handlerRejectChirp handles rejecting a chirp that is pending review.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Only admins may moderate chirps. A rejected chirp stays visible to its author, who may still delete it.
+// Returns a JSON response containing the rejected chirp.
+func (cfg *apiConfig) handlerRejectChirp(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirp
+	}
+
+	err := cfg.validateAdminKey(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate API key", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	chirp, err := cfg.db.UpdateChirpStatus(r.Context(), database.UpdateChirpStatusParams{
+		Status:     chirpStatusRejected,
+		ID:         chirpID,
+		FromStatus: chirpStatusPendingReview,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp pending review", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reject chirp", err)
+		return
+	}
+
+	chirps := []Chirp{databaseChirpToChirp(chirp)}
+	err = cfg.hydrateChirps(r.Context(), uuid.Nil, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirp: chirps[0],
+	})
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// The types of a notification, see the check constraint of notifications.type.
+const (
+	notificationTypeLike    = "like"
+	notificationTypeReply   = "reply"
+	notificationTypeFollow  = "follow"
+	notificationTypeMention = "mention"
+)
+
+// notify stores a notification of what a user did for each of the users concerned.
+//
+// It takes a context, the queries to run it with (usually bound to the transaction of the action itself),
+// the type of the notification, the ID of the user who acted, the ID of the chirp the notification is about,
+// the ID of the chirp its group is about, both uuid.Nil for a follow, and the IDs of the users to notify.
+// The group is about the chirp that was liked or replied to, so the replies to a chirp are grouped.
+// Users who turned the type off, or who blocked, were blocked by or muted the actor aren't notified.
+// Returns the notifications stored, and an error if the query fails.
+func notify(ctx context.Context, q *database.Queries, notificationType string, actorID, chirpID, subjectChirpID uuid.UUID, userIDs []uuid.UUID) ([]database.Notification, error) {
+	return q.CreateNotifications(ctx, database.CreateNotificationsParams{
+		UserIds:        userIDs,
+		ActorID:        actorID,
+		Type:           notificationType,
+		ChirpID:        uuid.NullUUID{UUID: chirpID, Valid: chirpID != uuid.Nil},
+		SubjectChirpID: uuid.NullUUID{UUID: subjectChirpID, Valid: subjectChirpID != uuid.Nil},
+	})
+}
+
+// notificationText describes a group of notifications, like "alice and 4 others liked your chirp".
+//
+// It takes the type of the notifications, the latest actors and the number of actors in the group.
+// Returns the description.
+func notificationText(notificationType string, actors []UserSummary, actorCount int64) string {
+	names := make([]string, 0, len(actors))
+	for _, actor := range actors {
+		name := actor.DisplayName
+		if name == "" {
+			name = "@" + actor.Handle
+		}
+		names = append(names, name)
+	}
+
+	who := "Someone"
+	switch {
+	case len(names) == 0:
+	case actorCount == 1:
+		who = names[0]
+	case actorCount == 2 && len(names) == 2:
+		who = names[0] + " and " + names[1]
+	case actorCount == 2:
+		who = names[0] + " and 1 other"
+	default:
+		who = fmt.Sprintf("%s and %d others", names[0], actorCount-1)
+	}
+
+	switch notificationType {
+	case notificationTypeLike:
+		return who + " liked your chirp"
+	case notificationTypeReply:
+		return who + " replied to your chirp"
+	case notificationTypeFollow:
+		return who + " followed you"
+	case notificationTypeMention:
+		return who + " mentioned you"
+	}
+	return who + " did something"
+}
+
+// handlerGetNotifications handles the listing of the notifications of the user, one page at a time.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Notifications of the same type about the same chirp on the same day are grouped, with the latest actors of each group.
+// The groups that began last come first, a group keeps its place as notifications join it,
+// the optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of notifications, the number of unread notifications and the cursor of the next page.
+func (cfg *apiConfig) handlerGetNotifications(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Notifications []Notification `json:"notifications"`
+		UnreadCount   int64          `json:"unread_count"`
+		NextCursor    string         `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	groups, err := cfg.db.ListNotificationGroups(r.Context(), database.ListNotificationGroupsParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve notifications", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(groups) > int(page.Limit) {
+		groups = groups[:page.Limit]
+		last := groups[len(groups)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	actorIDs := []uuid.UUID{}
+	for _, group := range groups {
+		actorIDs = append(actorIDs, group.ActorIds...)
+	}
+	actorByID := map[uuid.UUID]UserSummary{}
+	if len(actorIDs) > 0 {
+		actors, err := cfg.db.GetUsersByIDs(r.Context(), actorIDs)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve notifications", err)
+			return
+		}
+		for _, actor := range actors {
+			actorByID[actor.ID] = databaseUserToUserSummary(actor)
+		}
+	}
+
+	notifications := make([]Notification, 0, len(groups))
+	for _, group := range groups {
+		notification := Notification{
+			ID:          group.ID,
+			CreatedAt:   group.LatestAt,
+			Type:        group.Type,
+			Actors:      []UserSummary{},
+			ActorCount:  group.ActorCount,
+			UnreadCount: group.UnreadCount,
+		}
+		if group.SubjectChirpID.Valid {
+			notification.ChirpID = &group.SubjectChirpID.UUID
+		}
+		for _, actorID := range group.ActorIds {
+			if actor, ok := actorByID[actorID]; ok {
+				notification.Actors = append(notification.Actors, actor)
+			}
+		}
+		notification.Text = notificationText(group.Type, notification.Actors, group.ActorCount)
+		notifications = append(notifications, notification)
+	}
+
+	unreadCount, err := cfg.db.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve notifications", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Notifications: notifications,
+		UnreadCount:   unreadCount,
+		NextCursor:    nextCursor,
+	})
+}
+
+// handlerMarkNotificationRead handles marking a group of notifications read.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Marking a notification read again succeeds without changing anything.
+// Returns a 204 No Content response if the notifications are marked read, or an error response otherwise.
+func (cfg *apiConfig) handlerMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	notificationIDString := r.PathValue("notificationID")
+	notificationID, err := uuid.Parse(notificationIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid notification ID", err)
+		return
+	}
+
+	marked, err := cfg.db.MarkNotificationGroupRead(r.Context(), database.MarkNotificationGroupReadParams{
+		ID:     notificationID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark notification read", err)
+		return
+	}
+	if marked == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find notification", errors.New("no notification of the user with this ID"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerMarkAllNotificationsRead handles marking every notification of the user read.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Returns a 204 No Content response if the notifications are marked read, or an error response otherwise.
+func (cfg *apiConfig) handlerMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	err = cfg.db.MarkAllNotificationsRead(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark notifications read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetNotificationPreferences handles retrieving which types of notifications the user receives.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Returns a JSON response containing the notification preferences.
+func (cfg *apiConfig) handlerGetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		NotificationPreferences
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		NotificationPreferences: databaseUserToNotificationPreferences(user),
+	})
+}
+
+// handlerUpdateNotificationPreferences handles changing which types of notifications the user receives.
+//
+// It expects a JSON payload in the request body with any of the boolean fields "likes", "replies", "follows" and "mentions",
+// the types left out keep their setting. Turning a type off doesn't remove the notifications already received.
+// Returns a JSON response containing the notification preferences.
+func (cfg *apiConfig) handlerUpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Likes    *bool `json:"likes"`
+		Replies  *bool `json:"replies"`
+		Follows  *bool `json:"follows"`
+		Mentions *bool `json:"mentions"`
+	}
+
+	type response struct {
+		NotificationPreferences
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+
+	update := database.UpdateNotificationPreferencesParams{
+		NotifyLikes:    user.NotifyLikes,
+		NotifyReplies:  user.NotifyReplies,
+		NotifyFollows:  user.NotifyFollows,
+		NotifyMentions: user.NotifyMentions,
+		ID:             userID,
+	}
+	if params.Likes != nil {
+		update.NotifyLikes = *params.Likes
+	}
+	if params.Replies != nil {
+		update.NotifyReplies = *params.Replies
+	}
+	if params.Follows != nil {
+		update.NotifyFollows = *params.Follows
+	}
+	if params.Mentions != nil {
+		update.NotifyMentions = *params.Mentions
+	}
+
+	user, err = cfg.db.UpdateNotificationPreferences(r.Context(), update)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update notification preferences", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		NotificationPreferences: databaseUserToNotificationPreferences(user),
+	})
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/mailer"
+)
+
+const (
+	// passwordResetTokenTTL is how long a password reset token can be used, as set by CreatePasswordResetToken
+	passwordResetTokenTTL = time.Hour
+	// maxPasswordResetsPerHour is how many password reset emails a user receives in an hour at most, so nobody can flood an inbox,
+	// see CountRecentPasswordResetTokens
+	maxPasswordResetsPerHour = 3
+	// passwordResetMailTimeout is how long looking up the user and sending a password reset email may take
+	passwordResetMailTimeout = 30 * time.Second
+)
+
+// hashResetToken returns the SHA-256 of a password reset token, the only form of it that is stored.
+//
+// It takes the token as sent to the user.
+// Returns the hex encoded hash.
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// passwordResetMessage writes the email carrying a password reset token.
+//
+// It takes the email address of the user and the token.
+// The email links to the password reset page when PASSWORD_RESET_URL is set, and gives the token itself otherwise.
+// Returns the message.
+func (cfg *apiConfig) passwordResetMessage(email, token string) mailer.Message {
+	action := "use this token to choose a new password: " + token
+	if cfg.passwordResetURL != "" {
+		action = "follow this link to choose a new password: " + cfg.passwordResetURL + "?token=" + url.QueryEscape(token)
+	}
+	return mailer.Message{
+		To:      email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your Chirpy account.\n\n"+
+			"If it was you, %s\n\n"+
+			"It works once, within %d minutes. If it wasn't you, ignore this email, your password stays the same.\n",
+			action, int(passwordResetTokenTTL.Minutes())),
+	}
+}
+
+// handlerPasswordResetRequest handles a request to reset the password of an account, sending a one-time token by email.
+//
+// It expects a JSON payload in the request body with the field "email".
+// The response is the same whether or not an account has the email, so it can't be used to find out who has an account.
+// Everything past checking the payload is done in the background, so not even the time taken to respond tells it.
+// Returns a 202 Accepted response, or 503 Service Unavailable if the server has no way to send emails.
+func (cfg *apiConfig) handlerPasswordResetRequest(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+
+	if cfg.mailer == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Password reset is not available", errors.New("no mailer configured"))
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	if params.Email == "" {
+		respondWithError(w, http.StatusBadRequest, "Email is required", errors.New("empty email"))
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), passwordResetMailTimeout)
+		defer cancel()
+		err := cfg.sendPasswordReset(ctx, params.Email)
+		if err != nil {
+			log.Printf("Couldn't send password reset email: %v", err)
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sendPasswordReset emails a password reset token to the user with an email address, if there is one.
+//
+// It takes a context and the email address.
+// Nothing is sent to an unknown address, nor after the third request of the hour for the same user.
+// Returns an error if the token can't be stored or the email can't be sent.
+func (cfg *apiConfig) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := cfg.db.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	recent, err := cfg.db.CountRecentPasswordResetTokens(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if recent >= maxPasswordResetsPerHour {
+		return nil
+	}
+
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+	err = cfg.db.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash: hashResetToken(token),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return cfg.mailer.Send(ctx, cfg.passwordResetMessage(user.Email, token))
+}
+
+// handlerPasswordResetConfirm handles choosing a new password with a password reset token.
+//
+// It expects a JSON payload in the request body with the fields "token" and "password".
+// The token is used up, as are the other reset tokens of the user, and every refresh token of the user is revoked,
+// so the sessions on other devices end once their access token expires.
+// Returns a 204 No Content response if the password is changed, or 401 Unauthorized if the token is unknown, used or expired.
+func (cfg *apiConfig) handlerPasswordResetConfirm(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	if params.Token == "" || params.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "Token and password are required", errors.New("empty token or password"))
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	userID, err := qtx.UsePasswordResetToken(r.Context(), hashResetToken(params.Token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired reset token", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+
+	err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		ID:             userID,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+	err = qtx.UsePasswordResetTokensOfUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+	err = qtx.RevokeRefreshTokensOfUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke sessions", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/textlength"
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions = 2
+	maxPollOptions = 4
+	// maxPollOptionLength is the longest option label in user-perceived characters
+	maxPollOptionLength = 25
+	// a poll runs for at least minPollDuration and at most maxPollDuration after the chirp is published
+	minPollDuration = 5 * time.Minute
+	maxPollDuration = 7 * 24 * time.Hour
+)
+
+// pollParameters is the optional poll of a new chirp.
+type pollParameters struct {
+	Options  []string   `json:"options"`
+	ClosesAt *time.Time `json:"closes_at"`
+}
+
+// filterPoll validates the poll of a new chirp and runs its options through the content filter.
+//
+// It takes the poll and the publish time of the chirp, which is not valid for a chirp published right away.
+// Returns the option labels with the words of mask rules masked and the status the chirp is stored with,
+// or an error if the poll is invalid or an option uses a word of a reject rule.
+func (cfg *apiConfig) filterPoll(poll pollParameters, publishAt sql.NullTime) ([]string, string, error) {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return nil, "", fmt.Errorf("A poll has %d to %d options", minPollOptions, maxPollOptions)
+	}
+	if poll.ClosesAt == nil {
+		return nil, "", errors.New("closes_at is required")
+	}
+	opensAt := time.Now()
+	if publishAt.Valid {
+		opensAt = publishAt.Time
+	}
+	if poll.ClosesAt.Before(opensAt.Add(minPollDuration)) || poll.ClosesAt.After(opensAt.Add(maxPollDuration)) {
+		return nil, "", errors.New("A poll runs for 5 minutes to 7 days")
+	}
+
+	status := chirpStatusPublished
+	labels := make([]string, 0, len(poll.Options))
+	seen := map[string]bool{}
+	for _, option := range poll.Options {
+		length := textlength.Graphemes(option)
+		if length == 0 || length > maxPollOptionLength {
+			return nil, "", fmt.Errorf("A poll option is 1 to %d characters long", maxPollOptionLength)
+		}
+		if seen[option] {
+			return nil, "", errors.New("Poll options must be different")
+		}
+		seen[option] = true
+
+		label, optionStatus, err := cfg.filterChirp(option)
+		if err != nil {
+			return nil, "", err
+		}
+		if optionStatus == chirpStatusPendingReview {
+			status = chirpStatusPendingReview
+		}
+		labels = append(labels, label)
+	}
+	return labels, status, nil
+}
+
+// handlerVotePoll handles voting in the poll of a chirp.
+//
+// It expects a JSON payload in the request body with the field "option_id", one of the options of the poll.
+// A user votes once per poll, the vote can't be changed. Voting is possible until the poll closes.
+// The polls of users who blocked the user or were blocked by them are not found.
+// Returns a JSON response containing the chirp, with the results of the poll.
+func (cfg *apiConfig) handlerVotePoll(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		OptionID uuid.UUID `json:"option_id"`
+	}
+
+	type response struct {
+		Chirp
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpById(r.Context(), chirpID)
+	if err == nil && chirp.Status != chirpStatusPublished {
+		err = errors.New("the chirp is not published")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+
+	blocked, err := cfg.blockedBetween(r.Context(), chirp.UserID, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", errors.New("blocked"))
+		return
+	}
+
+	poll, err := cfg.db.GetPoll(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find poll", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't vote", err)
+		return
+	}
+	if !poll.ClosesAt.After(time.Now()) {
+		respondWithError(w, http.StatusConflict, "Poll is closed", errors.New("the poll is closed"))
+		return
+	}
+
+	// one vote per user is enforced by the primary key, and the option is checked against the poll by a foreign key
+	voted, err := cfg.db.CreatePollVote(r.Context(), database.CreatePollVoteParams{
+		UserID:   userID,
+		OptionID: params.OptionID,
+		ChirpID:  chirpID,
+		Now:      time.Now().UTC(),
+	})
+	if uniqueViolation(err) != "" {
+		respondWithError(w, http.StatusConflict, "Already voted", err)
+		return
+	}
+	if foreignKeyViolation(err) != "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid poll option", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't vote", err)
+		return
+	}
+	if voted == 0 {
+		// closed between the check above and the insert
+		respondWithError(w, http.StatusConflict, "Poll is closed", errors.New("the poll is closed"))
+		return
+	}
+
+	chirps := []Chirp{databaseChirpToChirp(chirp)}
+	err = cfg.hydrateChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, response{
+		Chirp: chirps[0],
+	})
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+)
+
+// handlerGetProfile handles the retrieval of a user's public profile.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The handle in the path is matched without regard to case.
+// Returns a JSON response containing the Profile, which never includes the email, or 404 Not Found for unknown handles.
+func (cfg *apiConfig) handlerGetProfile(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Profile
+	}
+
+	handle := r.PathValue("handle")
+	if !handlePattern.MatchString(handle) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", nil)
+		return
+	}
+
+	profile, err := cfg.db.GetUserProfileByHandle(r.Context(), handle)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Profile: databaseProfileToProfile(profile),
+	})
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/repost"
+	"github.com/google/uuid"
+)
+
+// handlerRechirp handles reposting a chirp as-is.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Rechirping a rechirp reposts its original. Rechirping is idempotent, a chirp can be rechirped only once by the same user.
+// Returns a JSON response containing the rechirp, with 201 Created for a new rechirp and 200 OK for an existing one.
+func (cfg *apiConfig) handlerRechirp(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirp
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	original, err := cfg.getRepostTarget(r.Context(), userID, chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+	repostOfID := uuid.NullUUID{UUID: original.ID, Valid: true}
+
+	status := http.StatusCreated
+	rechirp, err := cfg.db.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		UserID:     userID,
+		RepostOfID: repostOfID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// nothing was inserted, the user has already rechirped it
+		status = http.StatusOK
+		rechirp, err = cfg.db.GetRechirp(r.Context(), database.GetRechirpParams{
+			UserID:     userID,
+			RepostOfID: repostOfID,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rechirp", err)
+		return
+	}
+	if status == http.StatusCreated {
+		cfg.publishChirps(r.Context(), []database.Chirp{rechirp})
+	}
+
+	chirps := []Chirp{databaseChirpToChirp(rechirp)}
+	err = cfg.hydrateChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	respondWithJSON(w, status, response{
+		Chirp: chirps[0],
+	})
+}
+
+// handlerUndoRechirp handles removing the rechirp of a chirp.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The path holds the ID of the rechirped chirp, or of a rechirp of it, as when rechirping.
+// Undoing is idempotent, it succeeds when there is no rechirp.
+// Returns a 204 No Content response if the rechirp is removed, or an error response otherwise.
+func (cfg *apiConfig) handlerUndoRechirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// rechirps are stored against the original, unlike rechirping no visibility check applies, undoing is always allowed
+	chirp, err := cfg.db.GetChirpById(r.Context(), chirpID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't undo rechirp", err)
+		return
+	}
+	if err == nil {
+		targetID, ok := repost.Target(chirp)
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		chirpID = targetID
+	}
+
+	deleted, err := cfg.db.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:     userID,
+		RepostOfID: uuid.NullUUID{UUID: chirpID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't undo rechirp", err)
+		return
+	}
+	for _, rechirp := range deleted {
+		cfg.publishChirpDeleted(r.Context(), rechirp)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerQuoteChirp handles reposting a chirp with commentary.
+//
+// It expects a JSON payload in the request body with the field "body",
+// which goes through the same filtering and validation as a new chirp, a held back quote is answered with 202 Accepted.
+// Quoting a rechirp quotes its original.
+// Returns a JSON response containing the quote chirp with the quoted chirp embedded.
+func (cfg *apiConfig) handlerQuoteChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	type response struct {
+		Chirp
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	cleanedBody, status, err := cfg.filterChirp(params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	maxLength, err := cfg.chirpLengthLimit(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	validChirp, err := validateChirp(cleanedBody, maxLength)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	original, err := cfg.getRepostTarget(r.Context(), userID, chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	quote, err := qtx.CreateQuote(r.Context(), database.CreateQuoteParams{
+		Body:       validChirp,
+		UserID:     userID,
+		RepostOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+		Status:     status,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+
+	notifications, err := saveChirpEntities(r.Context(), qtx, quote)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save mentions and hashtags", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+	cfg.publishChirps(r.Context(), []database.Chirp{quote})
+	cfg.publishNotifications(r.Context(), notifications)
+
+	chirps := []Chirp{databaseChirpToChirp(quote)}
+	err = cfg.hydrateChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	responseStatus := http.StatusCreated
+	if quote.Status == chirpStatusPendingReview {
+		responseStatus = http.StatusAccepted
+	}
+	respondWithJSON(w, responseStatus, response{
+		Chirp: chirps[0],
+	})
+}
+
+// getRepostTarget returns the chirp that reposting the given chirp actually reposts.
+//
+// It takes a context, the ID of the user and the ID of the chirp the user wants to repost.
+// A rechirp has no content of its own, so its original is returned instead.
+// Returns an error if the chirp, or the original of a rechirp, doesn't exist, is not published,
+// or is by a user who blocked the user or was blocked by them.
+func (cfg *apiConfig) getRepostTarget(ctx context.Context, userID, chirpID uuid.UUID) (database.Chirp, error) {
+	chirp, err := cfg.db.GetChirpById(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	targetID, ok := repost.Target(chirp)
+	if !ok {
+		return database.Chirp{}, errors.New("the original of the rechirp has been deleted")
+	}
+	if targetID != chirp.ID {
+		chirp, err = cfg.db.GetChirpById(ctx, targetID)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if chirp.Status != chirpStatusPublished {
+		return database.Chirp{}, errors.New("the chirp is not published")
+	}
+	blocked, err := cfg.blockedBetween(ctx, userID, chirp.UserID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if blocked {
+		return database.Chirp{}, errors.New("the author of the chirp is blocked")
+	}
+	return chirp, nil
+}
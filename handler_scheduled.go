@@ -0,0 +1,231 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerGetScheduledChirps handles the listing of the scheduled chirps of the user, one page at a time.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The chirps come in the order they are to be published, the optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of scheduled chirps and the cursor of the next page.
+func (cfg *apiConfig) handlerGetScheduledChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	// the cursor holds the publish time, the order of this list
+	cursorPublishAt, cursorID := page.cursorParams()
+
+	dbChirps, err := cfg.db.ListScheduledChirps(r.Context(), database.ListScheduledChirpsParams{
+		UserID:          userID,
+		CursorPublishAt: cursorPublishAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(dbChirps) > int(page.Limit) {
+		dbChirps = dbChirps[:page.Limit]
+		last := dbChirps[len(dbChirps)-1]
+		nextCursor = encodeCursor(last.PublishAt.Time, last.ID)
+	}
+
+	chirps := make([]Chirp, 0, len(dbChirps))
+	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, databaseChirpToChirp(dbChirp))
+	}
+	err = cfg.hydrateChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerRescheduleChirp handles changing the publish time of a scheduled chirp.
+//
+// It expects a JSON payload in the request body with the field "publish_at", in the future and within a year.
+// Only the author may reschedule a chirp, and only while it is still scheduled.
+// A poll on the chirp closes as long after the new publish time as it did after the old one.
+// Returns a JSON response containing the rescheduled chirp.
+func (cfg *apiConfig) handlerRescheduleChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		PublishAt *time.Time `json:"publish_at"`
+	}
+
+	type response struct {
+		Chirp
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	params := parameters{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if params.PublishAt == nil {
+		respondWithError(w, http.StatusBadRequest, "publish_at is required", errors.New("missing publish_at"))
+		return
+	}
+	err = validatePublishAt(*params.PublishAt)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reschedule chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.ShiftPollOfScheduledChirp(r.Context(), database.ShiftPollOfScheduledChirpParams{
+		PublishAt: params.PublishAt.UTC(),
+		ID:        chirpID,
+		UserID:    userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reschedule chirp", err)
+		return
+	}
+	chirp, err := qtx.RescheduleChirp(r.Context(), database.RescheduleChirpParams{
+		PublishAt: sql.NullTime{Time: params.PublishAt.UTC(), Valid: true},
+		ID:        chirpID,
+		UserID:    userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find scheduled chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reschedule chirp", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reschedule chirp", err)
+		return
+	}
+
+	chirps := []Chirp{databaseChirpToChirp(chirp)}
+	err = cfg.hydrateChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirp: chirps[0],
+	})
+}
+
+// handlerCancelScheduledChirp handles cancelling a scheduled chirp, which deletes it.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Only the author may cancel a chirp, and only while it is still scheduled, a published chirp is deleted as usual.
+// Returns a 204 No Content response if the chirp is cancelled, or an error response otherwise.
+func (cfg *apiConfig) handlerCancelScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	attachments, err := cfg.db.GetAttachmentsForChirps(r.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't cancel chirp", err)
+		return
+	}
+
+	// the status is checked by the delete itself, the publisher may publish the chirp at any moment
+	deleted, err := cfg.db.DeleteScheduledChirp(r.Context(), database.DeleteScheduledChirpParams{
+		ID:     chirpID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't cancel chirp", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find scheduled chirp", errors.New("no scheduled chirp with this ID"))
+		return
+	}
+
+	keys := []string{}
+	for _, attachment := range attachments {
+		keys = append(keys, attachment.StorageKey, attachment.ThumbnailKey)
+	}
+	cfg.deleteBlobs(keys)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/search"
+	"github.com/google/uuid"
+)
+
+// SearchResult is a chirp matching a search query together with its relevance and a highlighted snippet.
+type SearchResult struct {
+	Chirp
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// handlerSearchChirps handles the full-text search of chirp bodies.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The q query string is required, words are combined with AND, "quoted phrases" and prefix* terms are supported.
+// The optional author_id, limit and cursor query strings work like in handlerGetAllChirps.
+// Returns a JSON response containing a page of SearchResult objects ordered by relevance and the cursor of the next page.
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Results    []SearchResult `json:"results"`
+		NextCursor string         `json:"next_cursor,omitempty"`
+	}
+
+	query, err := search.BuildQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid search query", err)
+		return
+	}
+
+	authorID := uuid.NullUUID{}
+	authorIDString := r.URL.Query().Get("author_id")
+	if authorIDString != "" {
+		id, err := uuid.Parse(authorIDString)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid author ID", err)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorRank := sql.NullFloat64{}
+	if page.Cursor != nil {
+		if page.Cursor.Rank == nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor", errors.New("search cursor without rank"))
+			return
+		}
+		cursorRank = sql.NullFloat64{Float64: float64(*page.Cursor.Rank), Valid: true}
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	viewerID := cfg.viewerID(r)
+	rows, err := cfg.db.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:           query,
+		ViewerID:        viewerID,
+		AuthorID:        authorID,
+		CursorRank:      cursorRank,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search chirps", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(rows) > int(page.Limit) {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		nextCursor = encodeRankedCursor(last.Rank, last.Chirp.CreatedAt, last.Chirp.ID)
+	}
+
+	chirps := make([]Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, databaseChirpToChirp(row.Chirp))
+	}
+	err = cfg.hydrateChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search chirps", err)
+		return
+	}
+
+	results := make([]SearchResult, 0, len(rows))
+	for i, row := range rows {
+		results = append(results, SearchResult{
+			Chirp:   chirps[i],
+			Rank:    row.Rank,
+			Snippet: search.Highlight(row.Snippet),
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Results:    results,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/realtime"
+)
+
+const (
+	// streamHeartbeatInterval is how often an idle stream sends a comment, so proxies don't close the connection
+	streamHeartbeatInterval = 15 * time.Second
+	// streamRetry is how long clients wait before reconnecting to an ended stream, in milliseconds
+	streamRetry = 3000
+)
+
+// handlerStream handles the Server-Sent Events stream of new chirps, deleted chirps, notifications and account changes.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The events are "chirp", "chirp_deleted", "notification" and "user_upgraded", each with a JSON payload and an ID.
+// A client reconnecting with the Last-Event-ID header receives the events it missed, as far as they are still kept.
+// Each instance of the server numbers the events itself, so resuming on another instance may repeat or skip events.
+// The chirps of users the user blocked, was blocked by or muted are left out, as are the rechirps and quotes of them.
+// A client that falls too far behind has its stream ended, and resumes by reconnecting. Streams end when the server stops.
+// Returns a text/event-stream response that lasts until the client disconnects.
+func (cfg *apiConfig) handlerStream(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	lastEventID := uint64(0)
+	lastEventIDString := r.Header.Get("Last-Event-ID")
+	if lastEventIDString != "" {
+		lastEventID, err = strconv.ParseUint(lastEventIDString, 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid Last-Event-ID", err)
+			return
+		}
+	}
+
+	sub, err := cfg.hub.Subscribe(userID, lastEventID)
+	if errors.Is(err, realtime.ErrClosed) {
+		respondWithError(w, http.StatusServiceUnavailable, "Server is shutting down", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't open stream", err)
+		return
+	}
+	defer sub.Close()
+
+	// the hidden users are loaded after subscribing, so a change between the two still reloads them
+	hidden, err := cfg.newHiddenActors(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve hidden users", err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// nginx buffers responses unless told otherwise
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_, err = fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+	if err == nil {
+		err = rc.Flush()
+	}
+	if err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if event.Type == eventTypeHiddenUsersChanged {
+				err = hidden.load(r.Context())
+				if err != nil {
+					return
+				}
+				continue
+			}
+			if hidden.hides(event) {
+				continue
+			}
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/entities"
+)
+
+const (
+	// trendWindow is how recently a tag must have been used to be trending
+	trendWindow          = 48 * time.Hour
+	defaultTrendingLimit = 10
+	maxTrendingLimit     = 50
+)
+
+// TrendingTag is a tag together with its current trend score.
+type TrendingTag struct {
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+}
+
+// handlerGetTagChirps handles the retrieval of the chirps that use a hashtag.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The tag in the path may have a leading # and is matched in its normalized form, so #Go and #go are the same tag.
+// The optional limit and cursor query strings select the page.
+// Returns a JSON response containing a page of chirps, newest first, and the cursor of the next page.
+func (cfg *apiConfig) handlerGetTagChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Tag        string  `json:"tag"`
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	tag := strings.TrimPrefix(r.PathValue("tag"), "#")
+	if !entities.ValidHashtag(tag) {
+		respondWithError(w, http.StatusBadRequest, "Invalid tag", errors.New("not a hashtag"))
+		return
+	}
+	tag = entities.NormalizeHashtag(tag)
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	viewerID := cfg.viewerID(r)
+	dbChirps, err := cfg.db.ListChirpsForTag(r.Context(), database.ListChirpsForTagParams{
+		Tag:             tag,
+		ViewerID:        viewerID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	chirps, nextCursor := chirpsPage(dbChirps, page.Limit)
+	err = cfg.hydrateChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Tag:        tag,
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerGetTrendingTags handles the retrieval of the trending hashtags.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Every use of a tag adds one to its score, and the score halves every trendHalfLife,
+// so recent use outweighs old use. Tags unused for longer than trendWindow are not trending at all.
+// The scores are kept up to date as chirps are written, reading them doesn't scan any chirps.
+// The optional limit query string (1-50, default 10) sets the number of tags.
+// Returns a JSON response containing the trending tags, highest score first.
+func (cfg *apiConfig) handlerGetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Tags []TrendingTag `json:"tags"`
+	}
+
+	limit := defaultTrendingLimit
+	limitString := r.URL.Query().Get("limit")
+	if limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxTrendingLimit {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", errors.New("limit out of range"))
+			return
+		}
+	}
+
+	rows, err := cfg.db.ListTrendingTags(r.Context(), database.ListTrendingTagsParams{
+		HalfLifeSeconds: trendHalfLife.Seconds(),
+		WindowSeconds:   trendWindow.Seconds(),
+		Limit:           int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve trending tags", err)
+		return
+	}
+
+	tags := make([]TrendingTag, 0, len(rows))
+	for _, row := range rows {
+		tags = append(tags, TrendingTag{
+			Tag:   row.Name,
+			Score: row.Score,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Tags: tags,
+	})
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultThreadDepth = 3
+	maxThreadDepth     = 10
+	// maxAncestorDepth guards the walk up the thread against pathological reply chains
+	maxAncestorDepth = 100
+)
+
+// ThreadNode is a reply in a conversation together with the replies to it.
+type ThreadNode struct {
+	Chirp
+	Replies []ThreadNode `json:"replies"`
+}
+
+// handlerGetChirpThread handles the retrieval of the conversation around a chirp.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// Returns a JSON response with the chirp, its ancestors from the start of the conversation down to its parent,
+// and the tree of replies under it.
+// The direct replies are paginated with the limit and cursor query strings, oldest first,
+// the optional depth query string (1-10, default 3) limits how deep the reply tree goes.
+// A chirp that is pending review or rejected is found only by its author, replies are listed only when published.
+// Chirps hidden from the viewer by a block or a mute are left out, together with the replies under them.
+func (cfg *apiConfig) handlerGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirp      Chirp        `json:"chirp"`
+		Ancestors  []Chirp      `json:"ancestors"`
+		Replies    []ThreadNode `json:"replies"`
+		NextCursor string       `json:"next_cursor,omitempty"`
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	depth := defaultThreadDepth
+	depthString := r.URL.Query().Get("depth")
+	if depthString != "" {
+		depth, err = strconv.Atoi(depthString)
+		if err != nil || depth < 1 || depth > maxThreadDepth {
+			respondWithError(w, http.StatusBadRequest, "Invalid depth", errors.New("depth out of range"))
+			return
+		}
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorParams()
+
+	viewerID := cfg.viewerID(r)
+	dbChirp, err := cfg.db.GetChirpById(r.Context(), chirpID)
+	if err == nil && !chirpVisibleTo(dbChirp, viewerID) {
+		err = errors.New("the chirp is not published")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+	blocked, err := cfg.blockedBetween(r.Context(), dbChirp.UserID, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", errors.New("blocked"))
+		return
+	}
+
+	dbAncestors, err := cfg.db.GetThreadAncestors(r.Context(), database.GetThreadAncestorsParams{
+		ChirpID:  chirpID,
+		MaxDepth: maxAncestorDepth,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve thread", err)
+		return
+	}
+
+	rows, err := cfg.db.GetThreadReplies(r.Context(), database.GetThreadRepliesParams{
+		ChirpID:         chirpID,
+		ViewerID:        viewerID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.fetchLimit(),
+		MaxDepth:        int32(depth),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve thread", err)
+		return
+	}
+
+	// rows come ordered by depth, so the direct replies are the first ones
+	directReplies := []database.Chirp{}
+	for _, row := range rows {
+		if row.Depth == 1 {
+			directReplies = append(directReplies, row.Chirp)
+		}
+	}
+	nextCursor := ""
+	if len(directReplies) > int(page.Limit) {
+		last := directReplies[page.Limit-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	// one flat slice, so a single hydrateChirps call covers the whole thread
+	chirps := []Chirp{databaseChirpToChirp(dbChirp)}
+	for _, dbAncestor := range dbAncestors {
+		chirps = append(chirps, databaseChirpToChirp(dbAncestor))
+	}
+	for _, row := range rows {
+		chirps = append(chirps, databaseChirpToChirp(row.Chirp))
+	}
+	err = cfg.hydrateChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve thread", err)
+		return
+	}
+
+	replies := chirps[1+len(dbAncestors):]
+	children := map[uuid.UUID][]Chirp{}
+	for _, reply := range replies {
+		children[*reply.InReplyTo] = append(children[*reply.InReplyTo], reply)
+	}
+
+	topLevel := children[chirpID]
+	if len(topLevel) > int(page.Limit) {
+		// the extra reply only told us there is a next page
+		topLevel = topLevel[:page.Limit]
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirp:      chirps[0],
+		Ancestors:  chirps[1 : 1+len(dbAncestors)],
+		Replies:    buildThreadNodes(topLevel, children),
+		NextCursor: nextCursor,
+	})
+}
+
+// buildThreadNodes turns a list of replies into ThreadNodes, recursively attaching their own replies.
+//
+// It takes the replies of one level and a map from chirp ID to the replies of that chirp.
+// Returns the ThreadNodes in the order of the replies.
+func buildThreadNodes(replies []Chirp, children map[uuid.UUID][]Chirp) []ThreadNode {
+	nodes := make([]ThreadNode, 0, len(replies))
+	for _, reply := range replies {
+		nodes = append(nodes, ThreadNode{
+			Chirp:   reply,
+			Replies: buildThreadNodes(children[reply.ID], children),
+		})
+	}
+	return nodes
+}
@@ -45,11 +45,13 @@ func (cfg *apiConfig) handlerUserUpgrade(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	_, err = cfg.db.UpgradeUserById(r.Context(), params.Data.UserID)
+	user, err := cfg.db.UpgradeUserById(r.Context(), params.Data.UserID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't upgrade user", err)
 		return
 	}
 
+	cfg.publishUserUpgraded(r.Context(), user)
+
 	w.WriteHeader(http.StatusNoContent)
 }
\ No newline at end of file
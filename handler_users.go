@@ -1,8 +1,16 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/ArrayOfLilly/chirp/internal/auth"
 	"github.com/ArrayOfLilly/chirp/internal/database"
@@ -10,12 +18,18 @@ import (
 
 // handlerUserCreate handles the user creation request.
 //
-// It expects a JSON payload in the request body with the fields "email" and "password".
-// It returns a JSON response with the created user information.
+// It expects a JSON payload in the request body with the fields "email" and "password",
+// and the optional profile fields "handle", "display_name", "bio" and "avatar_url".
+// Users who don't pick a handle get a generated one they can change later.
+// It returns a JSON response with the created user information, or 409 Conflict if the email or handle is taken.
 func (cfg *apiConfig) handlerUserCreate(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Email 		string `json:"email"`
 		Password 	string `json:"password"`
+		Handle		string `json:"handle"`
+		DisplayName	string `json:"display_name"`
+		Bio			string `json:"bio"`
+		AvatarURL	string `json:"avatar_url"`
 	}
 
 	type response struct {
@@ -31,6 +45,21 @@ func (cfg *apiConfig) handlerUserCreate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	handle := params.Handle
+	if handle == "" {
+		handle, err = generateHandle()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't generate handle", err)
+			return
+		}
+	}
+
+	err = validateProfile(handle, params.DisplayName, params.Bio, params.AvatarURL)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
@@ -40,9 +69,13 @@ func (cfg *apiConfig) handlerUserCreate(w http.ResponseWriter, r *http.Request)
 	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
 		Email: params.Email,
 		HashedPassword: hashedPassword,
+		Handle: handle,
+		DisplayName: params.DisplayName,
+		Bio: params.Bio,
+		AvatarUrl: params.AvatarURL,
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't create user", err)
+		respondWithUserWriteError(w, "Couldn't create user", err)
 		return
 	}
 
@@ -53,12 +86,18 @@ func (cfg *apiConfig) handlerUserCreate(w http.ResponseWriter, r *http.Request)
 
 // handlerUserpdate handles the user update request.
 //
-// It expects a JSON payload in the request body with the fields "email" and "password".
-// It responds with a JSON payload containing the updated user information.
+// It expects a JSON payload in the request body with any of the fields "email", "password",
+// "handle", "display_name", "bio" and "avatar_url". Missing fields are left unchanged,
+// display_name, bio and avatar_url can be cleared with an empty string.
+// It responds with a JSON payload containing the updated user information, or 409 Conflict if the email or handle is taken.
 func (cfg *apiConfig) handlerUserpdate(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Email 		string `json:"email"`
-		Password 	string `json:"password"`
+		Email 		string 	`json:"email"`
+		Password 	string 	`json:"password"`
+		Handle		string	`json:"handle"`
+		DisplayName	*string	`json:"display_name"`
+		Bio			*string	`json:"bio"`
+		AvatarURL	*string	`json:"avatar_url"`
 	}
 
 	type response struct {
@@ -111,17 +150,126 @@ func (cfg *apiConfig) handlerUserpdate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	updatedHandle := user.Handle
+	if params.Handle != "" {
+		updatedHandle = params.Handle
+	}
+	updatedDisplayName := user.DisplayName
+	if params.DisplayName != nil {
+		updatedDisplayName = *params.DisplayName
+	}
+	updatedBio := user.Bio
+	if params.Bio != nil {
+		updatedBio = *params.Bio
+	}
+	updatedAvatarURL := user.AvatarUrl
+	if params.AvatarURL != nil {
+		updatedAvatarURL = *params.AvatarURL
+	}
+
+	err = validateProfile(updatedHandle, updatedDisplayName, updatedBio, updatedAvatarURL)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
 	updatedUser, err := cfg.db.UpdateUserData(r.Context(), database.UpdateUserDataParams{
 		ID: 			user.ID,
 		Email: 			updatedEmail,
 		HashedPassword: updatedPassword,
+		Handle:			updatedHandle,
+		DisplayName:	updatedDisplayName,
+		Bio:			updatedBio,
+		AvatarUrl:		updatedAvatarURL,
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't update user data", err)
+		respondWithUserWriteError(w, "Couldn't update user data", err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, response{
 		User: databaseUserToUser(updatedUser),
 	})
-}
\ No newline at end of file
+}
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxAvatarURLLength   = 2048
+)
+
+// handlePattern is what a handle may look like: 3 to 15 ASCII letters, digits or underscores.
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,15}$`)
+
+// reservedHandles can't be taken by anyone, they would be mistaken for the service itself.
+var reservedHandles = []string{"admin", "administrator", "api", "chirp", "chirpy", "me", "moderator", "root", "support"}
+
+// validateProfile checks the public profile fields of a user.
+//
+// It takes the handle, the display name, the bio and the avatar URL as parameters.
+// Handles are compared without regard to case, so reserved handles are rejected in any case.
+// The display name and the bio are limited in characters, not bytes, and can't contain control characters.
+// The avatar URL is optional, if given it must be an absolute http or https URL.
+// Returns an error describing the first invalid field, or nil if all of them are valid.
+func validateProfile(handle, displayName, bio, avatarURL string) error {
+	if !handlePattern.MatchString(handle) {
+		return errors.New("Handle must be 3 to 15 letters, digits or underscores")
+	}
+	if contains(reservedHandles, strings.ToLower(handle)) {
+		return errors.New("Handle is reserved")
+	}
+
+	if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+		return errors.New("Display name is too long")
+	}
+	if strings.ContainsFunc(displayName, unicode.IsControl) {
+		return errors.New("Display name contains invalid characters")
+	}
+
+	if utf8.RuneCountInString(bio) > maxBioLength {
+		return errors.New("Bio is too long")
+	}
+	// line breaks are fine in a bio, other control characters are not
+	if strings.ContainsFunc(bio, func(r rune) bool { return unicode.IsControl(r) && r != '\n' }) {
+		return errors.New("Bio contains invalid characters")
+	}
+
+	if avatarURL == "" {
+		return nil
+	}
+	if len(avatarURL) > maxAvatarURLLength {
+		return errors.New("Avatar URL is too long")
+	}
+	u, err := url.Parse(avatarURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("Avatar URL must be an http or https URL")
+	}
+	return nil
+}
+
+// generateHandle generates a random handle for a user who didn't pick one.
+//
+// Returns a handle of the form "user_" followed by 10 hex digits, or an error if the random source fails.
+func generateHandle() (string, error) {
+	b := make([]byte, 5)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return "user_" + hex.EncodeToString(b), nil
+}
+
+// respondWithUserWriteError responds to a failed insert or update of a user.
+//
+// It takes an http.ResponseWriter, the message for unexpected errors and the error as parameters.
+// Violations of the unique email or handle are the client's fault and get a 409 Conflict, anything else a 500.
+func respondWithUserWriteError(w http.ResponseWriter, msg string, err error) {
+	switch uniqueViolation(err) {
+	case "users_email_key":
+		respondWithError(w, http.StatusConflict, "Email is already registered", err)
+	case "users_handle_lower_idx":
+		respondWithError(w, http.StatusConflict, "Handle is already taken", err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, msg, err)
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/ArrayOfLilly/chirp/internal/realtime"
+	"github.com/ArrayOfLilly/chirp/internal/websocket"
+	"github.com/google/uuid"
+)
+
+const (
+	// wsAuthTimeout is how long a client that didn't send the Authorization header has to send its auth message
+	wsAuthTimeout = 10 * time.Second
+	// wsPingInterval is how often the server pings the client, so dead connections are noticed and proxies keep them open
+	wsPingInterval = 30 * time.Second
+	// wsPongWait is how long the server waits for anything from the client, pongs included, before giving up on it
+	wsPongWait = 2 * wsPingInterval
+	// wsWriteWait is how long a single write to the client may take
+	wsWriteWait = 10 * time.Second
+	// wsMaxMessageSize is the longest message a client may send, in bytes
+	wsMaxMessageSize = 4096
+	// wsMaxSubscriptions is how many topics a connection may subscribe to at once
+	wsMaxSubscriptions = 20
+)
+
+// The types of the messages exchanged on a WebSocket connection.
+const (
+	wsMessageAuth         = "auth"
+	wsMessageSubscribe    = "subscribe"
+	wsMessageUnsubscribe  = "unsubscribe"
+	wsMessagePing         = "ping"
+	wsMessageAuthOK       = "auth_ok"
+	wsMessageSubscribed   = "subscribed"
+	wsMessageUnsubscribed = "unsubscribed"
+	wsMessagePong         = "pong"
+	wsMessageEvent        = "event"
+	wsMessageError        = "error"
+)
+
+// errInvalidWSMessage is returned by readWSMessage for a message that isn't JSON text, the connection can still be read from.
+var errInvalidWSMessage = errors.New("messages must be JSON text")
+
+// wsClientMessage is a message sent by a WebSocket client.
+type wsClientMessage struct {
+	Type  string `json:"type"`
+	Token string `json:"token,omitempty"`
+	Topic string `json:"topic,omitempty"`
+}
+
+// wsServerMessage is a message sent to a WebSocket client.
+type wsServerMessage struct {
+	Type   string          `json:"type"`
+	Topic  string          `json:"topic,omitempty"`
+	Topics []string        `json:"topics,omitempty"`
+	Event  string          `json:"event,omitempty"`
+	ID     uint64          `json:"id,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// parseTopic checks a topic a client subscribes to and puts it in its canonical form.
+//
+// It takes the topic: "feed", "notifications", "account", "author:<user ID>" or "thread:<chirp ID>".
+// Returns the topic, or an error if it isn't one of those.
+func parseTopic(topic string) (string, error) {
+	if topic == topicFeed || topic == topicNotifications || topic == topicAccount {
+		return topic, nil
+	}
+	kind, idString, found := strings.Cut(topic, ":")
+	if !found || (kind != topicAuthor && kind != topicThread) {
+		return "", fmt.Errorf("unknown topic %q", topic)
+	}
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return "", fmt.Errorf("invalid ID in topic %q", topic)
+	}
+	return kind + ":" + id.String(), nil
+}
+
+// writeWSMessage encodes a message and sends it to a WebSocket client.
+func writeWSMessage(conn *websocket.Conn, message wsServerMessage) error {
+	dat, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, dat, time.Now().Add(wsWriteWait))
+}
+
+// readWSMessage reads and decodes the next message of a WebSocket client.
+func readWSMessage(conn *websocket.Conn) (wsClientMessage, error) {
+	message := wsClientMessage{}
+	messageType, dat, err := conn.ReadMessage()
+	if err != nil {
+		return message, err
+	}
+	if messageType != websocket.TextMessage {
+		return message, errInvalidWSMessage
+	}
+	err = json.Unmarshal(dat, &message)
+	if err != nil {
+		return message, errInvalidWSMessage
+	}
+	return message, nil
+}
+
+// handlerWebSocket handles the WebSocket connection pushing new chirps, deleted chirps and notifications to the topics a client follows.
+//
+// It takes an http.ResponseWriter and an http.Request as parameters.
+// The client authenticates with a JWT, either in the Authorization header of the handshake or, for browsers which can't set it,
+// in a first {"type": "auth", "token": ...} message sent within 10 seconds, which is answered with {"type": "auth_ok"}.
+// It then sends JSON messages {"type": "subscribe", "topic": ...} and {"type": "unsubscribe", "topic": ...},
+// answered with "subscribed" and "unsubscribed" messages, and {"type": "ping"}, answered with {"type": "pong"}.
+// The topics are "feed" for every new chirp, "author:<user ID>" for the chirps of a user, "thread:<chirp ID>" for a chirp
+// and the replies below it, "notifications" for the notifications of the user and "account" for the changes to their account,
+// like an upgrade to Chirpy Red. A connection follows at most 20 topics.
+// Events arrive as {"type": "event", "event": "chirp", "id": ..., "topics": [...], "data": {...}}, once whatever the number of
+// topics they match, with the event types of GET /api/stream. Requests that can't be served are answered with an "error" message.
+// The chirps of users the user blocked, was blocked by or muted are left out, as are the rechirps and quotes of them.
+// The server pings the client every 30 seconds and closes connections it hasn't heard from in a minute.
+// A client that falls too far behind has its connection closed, as do all clients when the server stops.
+// Returns a 101 Switching Protocols response, or an error response if the handshake or the Authorization header is invalid.
+func (cfg *apiConfig) handlerWebSocket(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.Nil
+	if r.Header.Get("Authorization") != "" {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+			return
+		}
+		userID, err = auth.ValidateJWT(token, cfg.jwtSecret)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+			return
+		}
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if errors.Is(err, websocket.ErrBadHandshake) {
+		respondWithError(w, http.StatusBadRequest, "Invalid WebSocket handshake", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't open WebSocket connection", err)
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(wsMaxMessageSize)
+
+	if userID == uuid.Nil {
+		conn.SetReadDeadline(time.Now().Add(wsAuthTimeout))
+		message, err := readWSMessage(conn)
+		if err != nil || message.Type != wsMessageAuth {
+			conn.WriteClose(websocket.ClosePolicyViolation, "expected an auth message")
+			return
+		}
+		userID, err = auth.ValidateJWT(message.Token, cfg.jwtSecret)
+		if err != nil {
+			conn.WriteClose(websocket.ClosePolicyViolation, "couldn't validate JWT")
+			return
+		}
+		err = writeWSMessage(conn, wsServerMessage{Type: wsMessageAuthOK})
+		if err != nil {
+			return
+		}
+	}
+
+	sub, err := cfg.hub.Subscribe(userID, 0)
+	if err != nil {
+		conn.WriteClose(websocket.CloseGoingAway, "server is shutting down")
+		return
+	}
+	defer sub.Close()
+
+	// the hidden users are loaded after subscribing, so a change between the two still reloads them
+	hidden, err := cfg.newHiddenActors(r.Context(), userID)
+	if err != nil {
+		conn.WriteClose(websocket.CloseInternalError, "couldn't retrieve hidden users")
+		return
+	}
+
+	// the messages of the client are read on their own goroutine, and handled here with the events
+	messages := make(chan wsClientMessage)
+	readErrors := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func() {
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	go func() {
+		for {
+			message, err := readWSMessage(conn)
+			if err != nil && !errors.Is(err, errInvalidWSMessage) {
+				readErrors <- err
+				return
+			}
+			conn.SetReadDeadline(time.Now().Add(wsPongWait))
+			if err != nil {
+				message = wsClientMessage{Type: wsMessageError}
+			}
+			select {
+			case messages <- message:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	pings := time.NewTicker(wsPingInterval)
+	defer pings.Stop()
+
+	subscriptions := map[string]bool{}
+
+	for {
+		select {
+		case <-readErrors:
+			return
+		case <-pings.C:
+			err = conn.WritePing(time.Now().Add(wsWriteWait))
+		case message := <-messages:
+			err = writeWSMessage(conn, handleWSMessage(message, subscriptions))
+		case event, ok := <-sub.C:
+			if !ok {
+				conn.WriteClose(websocket.CloseGoingAway, "connection ended by the server")
+				return
+			}
+			err = cfg.deliverWSEvent(r, conn, hidden, subscriptions, event)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handleWSMessage handles a message of an authenticated WebSocket client.
+//
+// It takes the message and the topics of the connection, which it updates.
+// Returns the answer to the message.
+func handleWSMessage(message wsClientMessage, subscriptions map[string]bool) wsServerMessage {
+	switch message.Type {
+	case wsMessagePing:
+		return wsServerMessage{Type: wsMessagePong}
+	case wsMessageSubscribe, wsMessageUnsubscribe:
+		topic, err := parseTopic(message.Topic)
+		if err != nil {
+			return wsServerMessage{Type: wsMessageError, Topic: message.Topic, Error: err.Error()}
+		}
+		if message.Type == wsMessageUnsubscribe {
+			delete(subscriptions, topic)
+			return wsServerMessage{Type: wsMessageUnsubscribed, Topic: topic}
+		}
+		if !subscriptions[topic] && len(subscriptions) >= wsMaxSubscriptions {
+			return wsServerMessage{
+				Type:  wsMessageError,
+				Topic: topic,
+				Error: fmt.Sprintf("a connection can subscribe to at most %d topics", wsMaxSubscriptions),
+			}
+		}
+		subscriptions[topic] = true
+		return wsServerMessage{Type: wsMessageSubscribed, Topic: topic}
+	case wsMessageAuth:
+		return wsServerMessage{Type: wsMessageError, Error: "already authenticated"}
+	case wsMessageError:
+		return wsServerMessage{Type: wsMessageError, Error: errInvalidWSMessage.Error()}
+	}
+	return wsServerMessage{Type: wsMessageError, Error: fmt.Sprintf("unknown message type %q", message.Type)}
+}
+
+// deliverWSEvent sends an event to a WebSocket client if it matches one of its topics.
+//
+// It takes the http.Request of the connection, the connection, the hidden users of the client, its topics and the event.
+// An event telling that the hidden users changed reloads them instead, the client isn't told.
+// Returns an error if the hidden users can't be reloaded or the write fails.
+func (cfg *apiConfig) deliverWSEvent(r *http.Request, conn *websocket.Conn, hidden *hiddenActors, subscriptions map[string]bool, event realtime.Event) error {
+	if event.Type == eventTypeHiddenUsersChanged {
+		return hidden.load(r.Context())
+	}
+
+	topics := []string{}
+	for _, topic := range event.Topics {
+		if subscriptions[topic] {
+			topics = append(topics, topic)
+		}
+	}
+	if len(topics) == 0 {
+		return nil
+	}
+
+	if hidden.hides(event) {
+		return nil
+	}
+
+	return writeWSMessage(conn, wsServerMessage{
+		Type:   wsMessageEvent,
+		Topics: topics,
+		Event:  event.Type,
+		ID:     event.ID,
+		Data:   event.Data,
+	})
+}
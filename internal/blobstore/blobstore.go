@@ -0,0 +1,21 @@
+// Package blobstore stores binary objects, like uploaded images, under string keys.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrInvalidKey is returned for keys that are not slash-separated relative paths, like "../secret" or "/a".
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Store stores blobs under keys made of slash-separated path segments, like "attachments/abc.jpg".
+type Store interface {
+	// Put stores the content read from r under key, replacing any blob already stored there.
+	Put(ctx context.Context, key string, contentType string, r io.Reader) error
+	// Delete removes the blob stored under key, it is not an error if there is none.
+	Delete(ctx context.Context, key string) error
+	// URL returns the URL clients can fetch the blob stored under key from.
+	URL(key string) string
+}
@@ -0,0 +1,130 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore is a Store keeping blobs as files in a directory of the local filesystem,
+// served by a file server mounted at its base URL.
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStore creates a LocalStore.
+//
+// It takes the directory to keep the blobs in, which is created if needed,
+// and the URL the directory is served from, like "/media".
+// Returns the LocalStore, or an error if the directory can't be created.
+func NewLocalStore(dir, baseURL string) (*LocalStore, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalStore{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+// Put stores the content read from r under key.
+//
+// The content is written to a temporary file first and renamed into place,
+// so readers never see a partially written blob. The content type is not stored, the file server derives it
+// from the extension of the key.
+func (s *LocalStore) Put(ctx context.Context, key string, contentType string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Delete removes the file of the blob stored under key.
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// URL returns the URL of the blob stored under key below the base URL of the store.
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// path returns the path of the file of the blob stored under key, or ErrInvalidKey.
+func (s *LocalStore) path(key string) (string, error) {
+	if !fs.ValidPath(key) || key == "." {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// ExcludeDir keeps a file server of another directory from serving the directory of a LocalStore inside it,
+// which would list the keys of the blobs.
+//
+// It takes the file server, the directory it serves and the directory of the store.
+// Requests for the directory of the store or anything in it are answered with 404 Not Found,
+// the file server is returned as is when the directory of the store isn't inside the directory it serves.
+// Returns the handler, or an error if the directories can't be made absolute.
+func ExcludeDir(next http.Handler, root, dir string) (http.Handler, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(absRoot, absDir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return next, nil
+	}
+	excluded := path.Clean("/" + filepath.ToSlash(rel))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested := path.Clean("/" + r.URL.Path)
+		if excluded == "/" || requested == excluded || strings.HasPrefix(requested, excluded+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), nil
+}
@@ -0,0 +1,150 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLocalStore tests storing, replacing and deleting blobs with a LocalStore.
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "/media/")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	for _, content := range []string{"first", "second"} {
+		err = store.Put(ctx, "attachments/a.png", "image/png", strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		got, err := os.ReadFile(filepath.Join(dir, "attachments", "a.png"))
+		if err != nil {
+			t.Fatalf("reading the blob: %v", err)
+		}
+		if string(got) != content {
+			t.Errorf("stored blob = %q, want %q", got, content)
+		}
+	}
+
+	if got := store.URL("attachments/a.png"); got != "/media/attachments/a.png" {
+		t.Errorf("URL() = %q, want %q", got, "/media/attachments/a.png")
+	}
+
+	err = store.Delete(ctx, "attachments/a.png")
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "attachments", "a.png")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("blob still exists after Delete(), stat error = %v", err)
+	}
+	err = store.Delete(ctx, "attachments/a.png")
+	if err != nil {
+		t.Errorf("Delete() of a missing blob error = %v, want nil", err)
+	}
+}
+
+// TestLocalStoreInvalidKeys tests that keys can't reach outside the directory of a LocalStore.
+func TestLocalStoreInvalidKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/media")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+
+	for _, key := range []string{"", ".", "../escape", "/absolute", "a/../../b", "a//b"} {
+		err := store.Put(context.Background(), key, "text/plain", strings.NewReader("x"))
+		if !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+// TestExcludeDir tests that a file server of a directory doesn't serve the blobs of a store inside it.
+func TestExcludeDir(t *testing.T) {
+	root := t.TempDir()
+	err := os.WriteFile(filepath.Join(root, "page.txt"), []byte("hello"), 0o644)
+	if err != nil {
+		t.Fatalf("writing the page: %v", err)
+	}
+	store, err := NewLocalStore(filepath.Join(root, "media"), "/media")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	err = store.Put(context.Background(), "attachments/a.png", "image/png", strings.NewReader("image"))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	handler, err := ExcludeDir(http.FileServer(http.Dir(root)), root, filepath.Join(root, "media"))
+	if err != nil {
+		t.Fatalf("ExcludeDir() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{
+			name:       "File outside the store",
+			path:       "/page.txt",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Directory of the store",
+			path:       "/media/",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Listing of the attachments",
+			path:       "/media/attachments/",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Blob",
+			path:       "/media/attachments/a.png",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Blob by an unclean path",
+			path:       "/x/../media/./attachments/a.png",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = tt.path
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET %s status = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// TestExcludeDirOutside tests that a store outside the served directory leaves every path to the file server.
+func TestExcludeDirOutside(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler, err := ExcludeDir(next, filepath.Join(t.TempDir(), "app"), filepath.Join(t.TempDir(), "media"))
+	if err != nil {
+		t.Fatalf("ExcludeDir() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/media/attachments/", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("GET /media/attachments/ status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,202 @@
+// Package contentfilter finds prohibited words in user content, however they are disguised,
+// and decides what happens to the content that uses them.
+package contentfilter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Action is what happens to content that uses the word of a rule.
+type Action string
+
+const (
+	// ActionMask replaces the word with asterisks.
+	ActionMask Action = "mask"
+	// ActionModerate holds the content back until a moderator approves it.
+	ActionModerate Action = "moderate"
+	// ActionReject refuses the content.
+	ActionReject Action = "reject"
+)
+
+// mask is what masked words are replaced with, whatever their length.
+const mask = "****"
+
+// ErrInvalidRule is returned for rules without a single word or with an unknown action.
+var ErrInvalidRule = errors.New("invalid content filter rule")
+
+// severity orders the actions, the most severe action of the matched rules applies to the content.
+var severity = map[Action]int{
+	ActionMask:     1,
+	ActionModerate: 2,
+	ActionReject:   3,
+}
+
+// Rule is a word the filter looks for and the action to take on content using it.
+type Rule struct {
+	Word   string
+	Action Action
+}
+
+// Result is the outcome of filtering a text.
+type Result struct {
+	// Text is the filtered text, with the words of mask rules masked.
+	Text string
+	// Action is the most severe action of the matched rules, empty if no rule matched.
+	Action Action
+	// Matches are the rules that matched, in the order their words appear.
+	Matches []Rule
+}
+
+// Filter checks texts against a set of rules. A Filter is immutable and safe for concurrent use,
+// changing the rules means building a new Filter.
+type Filter struct {
+	rules map[string]Rule
+}
+
+// New creates a Filter.
+//
+// It takes the rules, a word of a later rule replaces the same word, as normalized, of an earlier one.
+// Returns the Filter, or an error wrapping ErrInvalidRule for the first invalid rule.
+func New(rules []Rule) (*Filter, error) {
+	f := &Filter{rules: map[string]Rule{}}
+	for _, rule := range rules {
+		err := ValidateRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		f.rules[Normalize(rule.Word)] = rule
+	}
+	return f, nil
+}
+
+// ValidateRule checks that a rule is a single word with a known action.
+//
+// Returns nil for a valid rule, or an error wrapping ErrInvalidRule.
+func ValidateRule(rule Rule) error {
+	if _, ok := severity[rule.Action]; !ok {
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidRule, rule.Action)
+	}
+	if rule.Word == "" || Normalize(rule.Word) == "" {
+		return fmt.Errorf("%w: empty word", ErrInvalidRule)
+	}
+	for _, r := range rule.Word {
+		if !isWordRune(r) {
+			return fmt.Errorf("%w: %q is not a single word", ErrInvalidRule, rule.Word)
+		}
+	}
+	return nil
+}
+
+// Check filters a text.
+//
+// Words are compared whole and normalized, see Normalize, so "Kerfuffle!", "k3rfuffl3", "ｋｅｒｆｕｆｆｌｅ"
+// and "kеrfuffle" with a Cyrillic е all match the rule "kerfuffle", but "kerfuffled" doesn't.
+// Punctuation around a word is kept when the word is masked.
+// Returns the Result, the text is returned unchanged if no mask rule matched.
+func (f *Filter) Check(text string) Result {
+	result := Result{}
+	b := strings.Builder{}
+	written := 0
+
+	for start := 0; start < len(text); {
+		r, size := utf8.DecodeRuneInString(text[start:])
+		if !isWordRune(r) {
+			start += size
+			continue
+		}
+		end := start
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !isWordRune(r) {
+				break
+			}
+			end += size
+		}
+
+		// "kerfuffle!" is kerfuffle followed by punctuation, "$harbert" is sharbert in leetspeak
+		coreStart, coreEnd := trimSymbols(text, start, end)
+		matchStart, matchEnd := coreStart, coreEnd
+		rule, ok := f.rules[Normalize(text[coreStart:coreEnd])]
+		if !ok || coreStart == coreEnd {
+			matchStart, matchEnd = start, end
+			rule, ok = f.rules[Normalize(text[start:end])]
+		}
+
+		if ok {
+			result.Matches = append(result.Matches, rule)
+			if severity[rule.Action] > severity[result.Action] {
+				result.Action = rule.Action
+			}
+			if rule.Action == ActionMask {
+				b.WriteString(text[written:matchStart])
+				b.WriteString(mask)
+				written = matchEnd
+			}
+		}
+		start = end
+	}
+
+	b.WriteString(text[written:])
+	result.Text = b.String()
+	return result
+}
+
+// trimSymbols narrows the word text[start:end] to the part without leetspeak symbols at its ends.
+func trimSymbols(text string, start, end int) (int, int) {
+	for start < end {
+		r, size := utf8.DecodeRuneInString(text[start:end])
+		if !isSymbol(r) {
+			break
+		}
+		start += size
+	}
+	for end > start {
+		r, size := utf8.DecodeLastRuneInString(text[start:end])
+		if !isSymbol(r) {
+			break
+		}
+		end -= size
+	}
+	return start, end
+}
+
+// ParseRules reads rules from a word list.
+//
+// It takes a reader of the list, which has a rule per line: a word, optionally followed by its action
+// ("mask", "moderate" or "reject", mask by default). Empty lines and lines starting with # are ignored.
+// Returns the rules, or an error naming the line of the first invalid rule.
+func ParseRules(r io.Reader) ([]Rule, error) {
+	rules := []Rule{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: %w: expected a word and an optional action", line, ErrInvalidRule)
+		}
+		rule := Rule{Word: fields[0], Action: ActionMask}
+		if len(fields) == 2 {
+			rule.Action = Action(fields[1])
+		}
+		err := ValidateRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
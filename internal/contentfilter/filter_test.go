@@ -0,0 +1,172 @@
+package contentfilter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCheck tests the Check method of Filter with various test cases.
+//
+// It checks the disguises words are still found in, the masked text and the resulting action.
+func TestCheck(t *testing.T) {
+	filter, err := New([]Rule{
+		{Word: "kerfuffle", Action: ActionMask},
+		{Word: "sharbert", Action: ActionMask},
+		{Word: "fornax", Action: ActionModerate},
+		{Word: "zorp", Action: ActionReject},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		text       string
+		wantText   string
+		wantAction Action
+	}{
+		{
+			name:       "Clean text",
+			text:       "I had a great day",
+			wantText:   "I had a great day",
+			wantAction: "",
+		},
+		{
+			name:       "Plain word",
+			text:       "what a kerfuffle today",
+			wantText:   "what a **** today",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "Punctuation and case",
+			text:       "Kerfuffle! Sharbert, (kerfuffle)",
+			wantText:   "****! ****, (****)",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "Leetspeak",
+			text:       "k3rfuffl3 and $h4rb3rt",
+			wantText:   "**** and ****",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "Fullwidth",
+			text:       "ｋｅｒｆｕｆｆｌｅ",
+			wantText:   "****",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "Cyrillic homoglyph",
+			text:       "kеrfuffle",
+			wantText:   "****",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "Accents and combining marks",
+			text:       "kérfüffle kerfufflé",
+			wantText:   "**** ****",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "Zero-width space",
+			text:       "ker​fuffle",
+			wantText:   "****",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "Longer word",
+			text:       "kerfuffled",
+			wantText:   "kerfuffled",
+			wantAction: "",
+		},
+		{
+			name:       "Most severe action wins",
+			text:       "kerfuffle fornax",
+			wantText:   "**** fornax",
+			wantAction: ActionModerate,
+		},
+		{
+			name:       "Reject",
+			text:       "kerfuffle ZORP fornax",
+			wantText:   "**** ZORP fornax",
+			wantAction: ActionReject,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filter.Check(tt.text)
+			if got.Text != tt.wantText {
+				t.Errorf("Check(%q) Text = %q, want %q", tt.text, got.Text, tt.wantText)
+			}
+			if got.Action != tt.wantAction {
+				t.Errorf("Check(%q) Action = %q, want %q", tt.text, got.Action, tt.wantAction)
+			}
+		})
+	}
+}
+
+// TestNew tests the New function with invalid rules.
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{name: "Empty word", rule: Rule{Word: "", Action: ActionMask}},
+		{name: "Two words", rule: Rule{Word: "two words", Action: ActionMask}},
+		{name: "Unknown action", rule: Rule{Word: "word", Action: "explode"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New([]Rule{tt.rule})
+			if !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("New() error = %v, want ErrInvalidRule", err)
+			}
+		})
+	}
+}
+
+// TestParseRules tests the ParseRules function with various test cases.
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    []Rule
+		wantErr bool
+	}{
+		{
+			name: "Words and actions",
+			list: "# prohibited words\nkerfuffle\n\nfornax moderate\n  zorp reject  \n",
+			want: []Rule{
+				{Word: "kerfuffle", Action: ActionMask},
+				{Word: "fornax", Action: ActionModerate},
+				{Word: "zorp", Action: ActionReject},
+			},
+		},
+		{
+			name:    "Unknown action",
+			list:    "kerfuffle explode\n",
+			wantErr: true,
+		},
+		{
+			name:    "Too many fields",
+			list:    "kerfuffle mask now\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRules(strings.NewReader(tt.list))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package contentfilter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// homoglyphs maps letters of other scripts that look like Latin letters to those Latin letters,
+// so "kеrfuffle" spelled with a Cyrillic е matches "kerfuffle".
+var homoglyphs = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ї': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd',
+	'ԛ': 'q', 'ԝ': 'w', 'ɡ': 'g',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	// Latin letters that don't decompose into a base letter and an accent
+	'ı': 'i', 'ł': 'l', 'ø': 'o', 'đ': 'd', 'ħ': 'h', 'ŧ': 't', 'ß': 's',
+}
+
+// accents maps accented Latin letters to their base letter.
+var accents = map[rune]rune{
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'ā': 'a', 'ă': 'a', 'ą': 'a',
+	'ç': 'c', 'ć': 'c', 'ĉ': 'c', 'ċ': 'c', 'č': 'c',
+	'ď': 'd',
+	'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e', 'ē': 'e', 'ĕ': 'e', 'ė': 'e', 'ę': 'e', 'ě': 'e',
+	'ĝ': 'g', 'ğ': 'g', 'ġ': 'g', 'ģ': 'g',
+	'ĥ': 'h',
+	'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i', 'ĩ': 'i', 'ī': 'i', 'ĭ': 'i', 'į': 'i',
+	'ĵ': 'j',
+	'ķ': 'k',
+	'ĺ': 'l', 'ļ': 'l', 'ľ': 'l', 'ŀ': 'l',
+	'ñ': 'n', 'ń': 'n', 'ņ': 'n', 'ň': 'n',
+	'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o', 'ō': 'o', 'ŏ': 'o', 'ő': 'o',
+	'ŕ': 'r', 'ŗ': 'r', 'ř': 'r',
+	'ś': 's', 'ŝ': 's', 'ş': 's', 'š': 's', 'ș': 's',
+	'ţ': 't', 'ť': 't', 'ț': 't',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ũ': 'u', 'ū': 'u', 'ŭ': 'u', 'ů': 'u', 'ű': 'u', 'ų': 'u',
+	'ŵ': 'w',
+	'ý': 'y', 'ÿ': 'y', 'ŷ': 'y',
+	'ź': 'z', 'ż': 'z', 'ž': 'z',
+}
+
+// leet maps digits and symbols used as letters in leetspeak to those letters.
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l', '+': 't',
+}
+
+// Normalize returns the form words are compared in.
+//
+// It folds case, turns fullwidth forms into their ASCII counterparts, strips accents and combining marks,
+// maps look-alike letters of other scripts and leetspeak digits and symbols to Latin letters,
+// and drops invisible characters like zero-width spaces and soft hyphens.
+func Normalize(word string) string {
+	b := strings.Builder{}
+	for _, r := range word {
+		// fullwidth ASCII variants, like "ｋｅｒｆｕｆｆｌｅ"
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Cf, r) {
+			continue
+		}
+		r = unicode.ToLower(unicode.ToUpper(r))
+		if base, ok := accents[r]; ok {
+			r = base
+		}
+		if latin, ok := homoglyphs[r]; ok {
+			r = latin
+		}
+		if letter, ok := leet[r]; ok {
+			r = letter
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isWordRune reports whether r can be part of a word the filter looks at.
+// Leetspeak symbols count, so "$harbert" is one word, and so do invisible characters, so they can't split a word.
+func isWordRune(r rune) bool {
+	if r >= 0xFF01 && r <= 0xFF5E {
+		r -= 0xFEE0
+	}
+	if _, ok := leet[r]; ok {
+		return true
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Cf, r)
+}
+
+// isSymbol reports whether r is a leetspeak symbol that is not a letter or a digit, like $ or !.
+func isSymbol(r rune) bool {
+	if r >= 0xFF01 && r <= 0xFF5E {
+		r -= 0xFEE0
+	}
+	_, ok := leet[r]
+	return ok && !unicode.IsDigit(r)
+}
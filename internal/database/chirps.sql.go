@@ -7,29 +7,208 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countRepliesForChirps = `-- name: CountRepliesForChirps :many
+SELECT reply_to_id, COUNT(*) AS reply_count
+    FROM chirps 
+    WHERE reply_to_id = ANY($1::uuid[])
+        AND status = 'published'
+    GROUP BY reply_to_id
+`
+
+type CountRepliesForChirpsRow struct {
+	ReplyToID  uuid.NullUUID
+	ReplyCount int64
+}
+
+func (q *Queries) CountRepliesForChirps(ctx context.Context, chirpIds []uuid.UUID) ([]CountRepliesForChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countRepliesForChirps, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRepliesForChirpsRow
+	for rows.Next() {
+		var i CountRepliesForChirpsRow
+		if err := rows.Scan(
+			&i.ReplyToID,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countRepostsForChirps = `-- name: CountRepostsForChirps :many
+SELECT repost_of_id, 
+    COUNT(*) FILTER (WHERE repost_kind = 'rechirp') AS rechirp_count,
+    COUNT(*) FILTER (WHERE repost_kind = 'quote') AS quote_count
+    FROM chirps 
+    WHERE repost_of_id = ANY($1::uuid[])
+        AND status = 'published'
+    GROUP BY repost_of_id
+`
+
+type CountRepostsForChirpsRow struct {
+	RepostOfID   uuid.NullUUID
+	RechirpCount int64
+	QuoteCount   int64
+}
+
+func (q *Queries) CountRepostsForChirps(ctx context.Context, chirpIds []uuid.UUID) ([]CountRepostsForChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countRepostsForChirps, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRepostsForChirpsRow
+	for rows.Next() {
+		var i CountRepostsForChirpsRow
+		if err := rows.Scan(
+			&i.RepostOfID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, reply_to_id, status, publish_at)
     VALUES (
         gen_random_uuid(), 
         NOW(), 
         NOW(), 
         $1, 
-        $2
+        $2,
+        $3,
+        $4,
+        $5
         )
-    RETURNING id, created_at, updated_at, body, user_id
+    RETURNING id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body      string
+	UserID    uuid.UUID
+	ReplyToID uuid.NullUUID
+	Status    string
+	PublishAt sql.NullTime
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.ReplyToID,
+		arg.Status,
+		arg.PublishAt,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ReplyToID,
+		&i.RepostKind,
+		&i.RepostOfID,
+		&i.Status,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const createQuote = `-- name: CreateQuote :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, repost_kind, repost_of_id, status)
+    VALUES (
+        gen_random_uuid(), 
+        NOW(), 
+        NOW(), 
+        $1, 
+        $2,
+        'quote',
+        $3,
+        $4
+        )
+    RETURNING id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
+`
+
+type CreateQuoteParams struct {
+	Body       string
+	UserID     uuid.UUID
+	RepostOfID uuid.NullUUID
+	Status     string
+}
+
+func (q *Queries) CreateQuote(ctx context.Context, arg CreateQuoteParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createQuote,
+		arg.Body,
+		arg.UserID,
+		arg.RepostOfID,
+		arg.Status,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ReplyToID,
+		&i.RepostKind,
+		&i.RepostOfID,
+		&i.Status,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, repost_kind, repost_of_id)
+    VALUES (
+        gen_random_uuid(), 
+        NOW(), 
+        NOW(), 
+        '', 
+        $1,
+        'rechirp',
+        $2
+        )
+    ON CONFLICT (user_id, repost_of_id) WHERE repost_kind = 'rechirp' DO NOTHING
+    RETURNING id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
+`
+
+type CreateRechirpParams struct {
+	UserID     uuid.UUID
+	RepostOfID uuid.NullUUID
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp, arg.UserID, arg.RepostOfID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +216,11 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ReplyToID,
+		&i.RepostKind,
+		&i.RepostOfID,
+		&i.Status,
+		&i.PublishAt,
 	)
 	return i, err
 }
@@ -50,14 +234,21 @@ func (q *Queries) DeleteChirpById(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id
-    FROM chirps 
-    ORDER BY created_at ASC
+const deleteRechirp = `-- name: DeleteRechirp :many
+DELETE FROM chirps 
+    WHERE user_id = $1 
+        AND repost_of_id = $2 
+        AND repost_kind = 'rechirp'
+    RETURNING id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+type DeleteRechirpParams struct {
+	UserID     uuid.UUID
+	RepostOfID uuid.NullUUID
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, deleteRechirp, arg.UserID, arg.RepostOfID)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +262,11 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.ReplyToID,
+			&i.RepostKind,
+			&i.RepostOfID,
+			&i.Status,
+			&i.PublishAt,
 		); err != nil {
 			return nil, err
 		}
@@ -86,7 +282,7 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpById = `-- name: GetChirpById :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
     FROM chirps 
     WHERE id = $1
 `
@@ -100,6 +296,639 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ReplyToID,
+		&i.RepostKind,
+		&i.RepostOfID,
+		&i.Status,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const getChirpByIdForUpdate = `-- name: GetChirpByIdForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
+    FROM chirps 
+    WHERE id = $1
+    FOR UPDATE
+`
+
+func (q *Queries) GetChirpByIdForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpByIdForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ReplyToID,
+		&i.RepostKind,
+		&i.RepostOfID,
+		&i.Status,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
+    FROM chirps 
+    WHERE id = ANY($1::uuid[])
+        AND status = 'published'
+        AND NOT hidden_from(user_id, $2)
+`
+
+type GetChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ReplyToID,
+			&i.RepostKind,
+			&i.RepostOfID,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRechirp = `-- name: GetRechirp :one
+SELECT id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
+    FROM chirps 
+    WHERE user_id = $1 
+        AND repost_of_id = $2 
+        AND repost_kind = 'rechirp'
+`
+
+type GetRechirpParams struct {
+	UserID     uuid.UUID
+	RepostOfID uuid.NullUUID
+}
+
+func (q *Queries) GetRechirp(ctx context.Context, arg GetRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getRechirp, arg.UserID, arg.RepostOfID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ReplyToID,
+		&i.RepostKind,
+		&i.RepostOfID,
+		&i.Status,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const getThreadAncestors = `-- name: GetThreadAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT chirps.id, chirps.reply_to_id, 0::int AS depth
+        FROM chirps 
+        WHERE chirps.id = $1
+    UNION ALL
+    SELECT chirps.id, chirps.reply_to_id, ancestors.depth + 1
+        FROM chirps 
+        JOIN ancestors ON chirps.id = ancestors.reply_to_id
+        WHERE ancestors.depth < $2::int
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.reply_to_id, chirps.repost_kind, chirps.repost_of_id, chirps.status, chirps.publish_at
+    FROM chirps 
+    JOIN ancestors ON chirps.id = ancestors.id
+    WHERE ancestors.depth > 0
+        AND chirps.status = 'published'
+        AND NOT hidden_from(chirps.user_id, $3)
+    ORDER BY ancestors.depth DESC
+`
+
+type GetThreadAncestorsParams struct {
+	ChirpID  uuid.UUID
+	MaxDepth int32
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetThreadAncestors(ctx context.Context, arg GetThreadAncestorsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getThreadAncestors, arg.ChirpID, arg.MaxDepth, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ReplyToID,
+			&i.RepostKind,
+			&i.RepostOfID,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getThreadReplies = `-- name: GetThreadReplies :many
+WITH RECURSIVE top_replies AS (
+    SELECT chirps.id
+        FROM chirps 
+        WHERE chirps.reply_to_id = $1
+            AND chirps.status = 'published'
+            AND NOT hidden_from(chirps.user_id, $2)
+            AND ($3::timestamp IS NULL 
+                OR (chirps.created_at, chirps.id) > ($3, $4::uuid))
+        ORDER BY chirps.created_at ASC, chirps.id ASC
+        LIMIT $5
+), descendants AS (
+    SELECT top_replies.id, 1::int AS depth
+        FROM top_replies
+    UNION ALL
+    SELECT chirps.id, descendants.depth + 1
+        FROM chirps 
+        JOIN descendants ON chirps.reply_to_id = descendants.id
+        WHERE descendants.depth < $6::int
+            AND chirps.status = 'published'
+            AND NOT hidden_from(chirps.user_id, $2)
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.reply_to_id, chirps.repost_kind, chirps.repost_of_id, chirps.status, chirps.publish_at, descendants.depth
+    FROM chirps 
+    JOIN descendants ON chirps.id = descendants.id
+    ORDER BY descendants.depth ASC, chirps.created_at ASC, chirps.id ASC
+`
+
+type GetThreadRepliesParams struct {
+	ChirpID         uuid.UUID
+	ViewerID        uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+	MaxDepth        int32
+}
+
+type GetThreadRepliesRow struct {
+	Chirp Chirp
+	Depth int32
+}
+
+func (q *Queries) GetThreadReplies(ctx context.Context, arg GetThreadRepliesParams) ([]GetThreadRepliesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getThreadReplies,
+		arg.ChirpID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+		arg.MaxDepth,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetThreadRepliesRow
+	for rows.Next() {
+		var i GetThreadRepliesRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.ReplyToID,
+			&i.Chirp.RepostKind,
+			&i.Chirp.RepostOfID,
+			&i.Chirp.Status,
+			&i.Chirp.PublishAt,
+			&i.Depth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
+    FROM chirps 
+    WHERE status = 'published'
+        AND NOT hidden_from(user_id, $1)
+        AND ($2::uuid IS NULL OR user_id = $2)
+        AND ($3::timestamp IS NULL 
+            OR (created_at, id) > ($3, $4::uuid))
+    ORDER BY created_at ASC, id ASC
+    LIMIT $5
+`
+
+type ListChirpsAscParams struct {
+	ViewerID        uuid.UUID
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ReplyToID,
+			&i.RepostKind,
+			&i.RepostOfID,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
+    FROM chirps 
+    WHERE status = 'published'
+        AND NOT hidden_from(user_id, $1)
+        AND ($2::uuid IS NULL OR user_id = $2)
+        AND ($3::timestamp IS NULL 
+            OR (created_at, id) < ($3, $4::uuid))
+    ORDER BY created_at DESC, id DESC
+    LIMIT $5
+`
+
+type ListChirpsDescParams struct {
+	ViewerID        uuid.UUID
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ReplyToID,
+			&i.RepostKind,
+			&i.RepostOfID,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsForModeration = `-- name: ListChirpsForModeration :many
+SELECT id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
+    FROM chirps 
+    WHERE status = 'pending_review'
+        AND ($1::timestamp IS NULL 
+            OR (created_at, id) > ($1, $2::uuid))
+    ORDER BY created_at ASC, id ASC
+    LIMIT $3
+`
+
+type ListChirpsForModerationParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListChirpsForModeration(ctx context.Context, arg ListChirpsForModerationParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsForModeration, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ReplyToID,
+			&i.RepostKind,
+			&i.RepostOfID,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTimeline = `-- name: ListTimeline :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.reply_to_id, chirps.repost_kind, chirps.repost_of_id, chirps.status, chirps.publish_at
+    FROM chirps 
+    JOIN (
+        SELECT follows.followee_id AS author_id
+            FROM follows 
+            WHERE follows.follower_id = $1
+        UNION ALL
+        SELECT $1::uuid
+    ) AS authors ON chirps.user_id = authors.author_id
+    WHERE chirps.status = 'published'
+        AND NOT hidden_from(chirps.user_id, $1)
+        AND ($2::timestamp IS NULL 
+            OR (chirps.created_at, chirps.id) < ($2, $3::uuid))
+    ORDER BY chirps.created_at DESC, chirps.id DESC
+    LIMIT $4
+`
+
+type ListTimelineParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListTimeline(ctx context.Context, arg ListTimelineParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listTimeline,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ReplyToID,
+			&i.RepostKind,
+			&i.RepostOfID,
+			&i.Status,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.reply_to_id, chirps.repost_kind, chirps.repost_of_id, chirps.status, chirps.publish_at, ranked.rank, 
+    ts_headline('english', chirps.body, to_tsquery('english', $1), 
+        E'StartSel=\uE000, StopSel=\uE001, MaxFragments=2, MaxWords=20, MinWords=5') AS snippet
+    FROM (
+        SELECT chirps.id,
+            ts_rank(chirp_search.search_vector, to_tsquery('english', $1)) AS rank
+        FROM chirp_search 
+        JOIN chirps ON chirps.id = chirp_search.chirp_id
+        WHERE chirp_search.search_vector @@ to_tsquery('english', $1)
+            AND chirps.status = 'published'
+            AND NOT hidden_from(chirps.user_id, $2)
+            AND ($3::uuid IS NULL OR chirps.user_id = $3)
+    ) AS ranked
+    JOIN chirps ON chirps.id = ranked.id
+    WHERE $4::real IS NULL 
+        OR (ranked.rank, chirps.created_at, chirps.id) < ($4, $5::timestamp, $6::uuid)
+    ORDER BY ranked.rank DESC, chirps.created_at DESC, chirps.id DESC
+    LIMIT $7
+`
+
+type SearchChirpsParams struct {
+	Query           string
+	ViewerID        uuid.UUID
+	AuthorID        uuid.NullUUID
+	CursorRank      sql.NullFloat64
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type SearchChirpsRow struct {
+	Chirp   Chirp
+	Rank    float32
+	Snippet string
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.CursorRank,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.ReplyToID,
+			&i.Chirp.RepostKind,
+			&i.Chirp.RepostOfID,
+			&i.Chirp.Status,
+			&i.Chirp.PublishAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps 
+    SET body = $2,
+    status = $3,
+    updated_at = NOW()
+    WHERE id = $1
+    RETURNING id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
+`
+
+type UpdateChirpBodyParams struct {
+	ID     uuid.UUID
+	Body   string
+	Status string
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body, arg.Status)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ReplyToID,
+		&i.RepostKind,
+		&i.RepostOfID,
+		&i.Status,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const updateChirpStatus = `-- name: UpdateChirpStatus :one
+UPDATE chirps 
+    SET status = $1,
+    updated_at = NOW()
+    WHERE id = $2
+        AND status = $3
+    RETURNING id, created_at, updated_at, body, user_id, reply_to_id, repost_kind, repost_of_id, status, publish_at
+`
+
+type UpdateChirpStatusParams struct {
+	Status     string
+	ID         uuid.UUID
+	FromStatus string
+}
+
+func (q *Queries) UpdateChirpStatus(ctx context.Context, arg UpdateChirpStatusParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpStatus, arg.Status, arg.ID, arg.FromStatus)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ReplyToID,
+		&i.RepostKind,
+		&i.RepostOfID,
+		&i.Status,
+		&i.PublishAt,
 	)
 	return i, err
 }
@@ -11,12 +11,191 @@ import (
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Body       string
+	UserID     uuid.UUID
+	ReplyToID  uuid.NullUUID
+	RepostKind sql.NullString
+	RepostOfID uuid.NullUUID
+	Status     string
+	PublishAt  sql.NullTime
+}
+
+type ChirpAttachment struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	ChirpID         uuid.UUID
+	Ordinal         int32
+	ContentType     string
+	StorageKey      string
+	Width           int32
+	Height          int32
+	ThumbnailKey    string
+	ThumbnailWidth  int32
+	ThumbnailHeight int32
+	ByteSize        int32
+	AltText         string
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+	ChirpID    uuid.UUID
+	Body       string
+}
+
+type ChirpSearch struct {
+	ChirpID      uuid.UUID
+	SearchVector interface{}
+}
+
+type ChirpTag struct {
+	ChirpID uuid.UUID
+	Tag     string
+}
+
+type ContentFilterRule struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Word      string
+	Action    string
+}
+
+type Conversation struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	IsGroup   bool
+}
+
+type ConversationParticipant struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	CreatedAt      time.Time
+	LastReadAt     sql.NullTime
+}
+
+type Draft struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
 	Body      string
+	ReplyToID uuid.NullUUID
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type List struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	Name        string
+	Description string
+	IsPrivate   bool
+}
+
+type ListMember struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Message struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	ActorID   uuid.UUID
+	Type      string
+	ChirpID   uuid.NullUUID
+	ReadAt    sql.NullTime
+	GroupID   uuid.UUID
+}
+
+type NotificationGroup struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UserID         uuid.UUID
+	Type           string
+	SubjectChirpID uuid.NullUUID
+	Day            time.Time
+}
+
+type PasswordResetToken struct {
+	TokenHash string
 	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	ClosesAt  time.Time
+}
+
+type PollOption struct {
+	ID       uuid.UUID
+	ChirpID  uuid.UUID
+	Position int32
+	Label    string
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	OptionID  uuid.UUID
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,6 +207,13 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type Tag struct {
+	Name           string
+	CreatedAt      time.Time
+	TrendScore     float64
+	TrendUpdatedAt time.Time
+}
+
 type User struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
@@ -35,4 +221,12 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	Handle         string
+	DisplayName    string
+	Bio            string
+	AvatarUrl      string
+	NotifyLikes    bool
+	NotifyReplies  bool
+	NotifyFollows  bool
+	NotifyMentions bool
 }
@@ -46,7 +46,7 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 }
 
 const getUserFromRefreshToken = `-- name: GetUserFromRefreshToken :one
-SELECT id, users.created_at, users.updated_at, email, hashed_password, is_chirpy_red, token, refresh_tokens.created_at, refresh_tokens.updated_at, user_id, expires_at, revoked_at 
+SELECT id, users.created_at, users.updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, notify_likes, notify_replies, notify_follows, notify_mentions, token, refresh_tokens.created_at, refresh_tokens.updated_at, user_id, expires_at, revoked_at 
     FROM users
     JOIN refresh_tokens 
         ON users.id = refresh_tokens.user_id
@@ -62,6 +62,14 @@ type GetUserFromRefreshTokenRow struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	Handle         string
+	DisplayName    string
+	Bio            string
+	AvatarUrl      string
+	NotifyLikes    bool
+	NotifyReplies  bool
+	NotifyFollows  bool
+	NotifyMentions bool
 	Token          string
 	CreatedAt_2    time.Time
 	UpdatedAt_2    time.Time
@@ -80,6 +88,14 @@ func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (Ge
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.NotifyLikes,
+		&i.NotifyReplies,
+		&i.NotifyFollows,
+		&i.NotifyMentions,
 		&i.Token,
 		&i.CreatedAt_2,
 		&i.UpdatedAt_2,
@@ -111,3 +127,16 @@ func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) (Refresh
 	)
 	return i, err
 }
+
+const revokeRefreshTokensOfUser = `-- name: RevokeRefreshTokensOfUser :exec
+UPDATE refresh_tokens 
+    SET revoked_at = NOW(),
+    updated_at = NOW()
+    WHERE user_id = $1 
+        AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokensOfUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokensOfUser, userID)
+	return err
+}
@@ -7,30 +7,47 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password, is_chirpy_red)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url)
     VALUES (
         gen_random_uuid(), 
         NOW(), 
         NOW(), 
         $1, 
         $2,
-        false
+        false,
+        $3,
+        $4,
+        $5,
+        $6
         )
-    RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+    RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, notify_likes, notify_replies, notify_follows, notify_mentions
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         string
+	DisplayName    string
+	Bio            string
+	AvatarUrl      string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarUrl,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -39,6 +56,14 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.NotifyLikes,
+		&i.NotifyReplies,
+		&i.NotifyFollows,
+		&i.NotifyMentions,
 	)
 	return i, err
 }
@@ -48,7 +73,7 @@ UPDATE users
     SET is_chirpy_red = false,
     updated_at = NOW()
     WHERE id = $1
-    RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+    RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, notify_likes, notify_replies, notify_follows, notify_mentions
 `
 
 func (q *Queries) DowngradeUserById(ctx context.Context, id uuid.UUID) (User, error) {
@@ -61,12 +86,20 @@ func (q *Queries) DowngradeUserById(ctx context.Context, id uuid.UUID) (User, er
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.NotifyLikes,
+		&i.NotifyReplies,
+		&i.NotifyFollows,
+		&i.NotifyMentions,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red 
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, notify_likes, notify_replies, notify_follows, notify_mentions 
     FROM users 
     WHERE email = $1
 `
@@ -81,12 +114,20 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.NotifyLikes,
+		&i.NotifyReplies,
+		&i.NotifyFollows,
+		&i.NotifyMentions,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red 
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, notify_likes, notify_replies, notify_follows, notify_mentions 
     FROM users 
     WHERE id = $1
 `
@@ -101,6 +142,189 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.NotifyLikes,
+		&i.NotifyReplies,
+		&i.NotifyFollows,
+		&i.NotifyMentions,
+	)
+	return i, err
+}
+
+const getUserProfileByHandle = `-- name: GetUserProfileByHandle :one
+SELECT id, created_at, handle, display_name, bio, avatar_url, is_chirpy_red,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id AND chirps.status = 'published') AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count
+    FROM users 
+    WHERE lower(handle) = lower($1)
+`
+
+type GetUserProfileByHandleRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	Handle         string
+	DisplayName    string
+	Bio            string
+	AvatarUrl      string
+	IsChirpyRed    bool
+	ChirpCount     int64
+	FollowerCount  int64
+	FollowingCount int64
+}
+
+func (q *Queries) GetUserProfileByHandle(ctx context.Context, handle string) (GetUserProfileByHandleRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserProfileByHandle, handle)
+	var i GetUserProfileByHandleRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.IsChirpyRed,
+		&i.ChirpCount,
+		&i.FollowerCount,
+		&i.FollowingCount,
+	)
+	return i, err
+}
+
+const getUsersByHandles = `-- name: GetUsersByHandles :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, notify_likes, notify_replies, notify_follows, notify_mentions 
+    FROM users 
+    WHERE lower(handle) = ANY($1::text[])
+`
+
+func (q *Queries) GetUsersByHandles(ctx context.Context, handles []string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByHandles, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.AvatarUrl,
+			&i.NotifyLikes,
+			&i.NotifyReplies,
+			&i.NotifyFollows,
+			&i.NotifyMentions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersByIDs = `-- name: GetUsersByIDs :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, notify_likes, notify_replies, notify_follows, notify_mentions 
+    FROM users 
+    WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.AvatarUrl,
+			&i.NotifyLikes,
+			&i.NotifyReplies,
+			&i.NotifyFollows,
+			&i.NotifyMentions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateNotificationPreferences = `-- name: UpdateNotificationPreferences :one
+UPDATE users 
+    SET notify_likes = $1,
+    notify_replies = $2,
+    notify_follows = $3,
+    notify_mentions = $4,
+    updated_at = NOW()
+    WHERE id = $5
+    RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, notify_likes, notify_replies, notify_follows, notify_mentions
+`
+
+type UpdateNotificationPreferencesParams struct {
+	NotifyLikes    bool
+	NotifyReplies  bool
+	NotifyFollows  bool
+	NotifyMentions bool
+	ID             uuid.UUID
+}
+
+func (q *Queries) UpdateNotificationPreferences(ctx context.Context, arg UpdateNotificationPreferencesParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateNotificationPreferences,
+		arg.NotifyLikes,
+		arg.NotifyReplies,
+		arg.NotifyFollows,
+		arg.NotifyMentions,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.NotifyLikes,
+		&i.NotifyReplies,
+		&i.NotifyFollows,
+		&i.NotifyMentions,
 	)
 	return i, err
 }
@@ -109,19 +333,35 @@ const updateUserData = `-- name: UpdateUserData :one
 UPDATE users 
     SET email = $2,
     hashed_password = $3,
+    handle = $4,
+    display_name = $5,
+    bio = $6,
+    avatar_url = $7,
     updated_at = NOW()
     WHERE id = $1
-    RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+    RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, notify_likes, notify_replies, notify_follows, notify_mentions
 `
 
 type UpdateUserDataParams struct {
 	ID             uuid.UUID
 	Email          string
 	HashedPassword string
+	Handle         string
+	DisplayName    string
+	Bio            string
+	AvatarUrl      string
 }
 
 func (q *Queries) UpdateUserData(ctx context.Context, arg UpdateUserDataParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUserData, arg.ID, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, updateUserData,
+		arg.ID,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarUrl,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -130,16 +370,41 @@ func (q *Queries) UpdateUserData(ctx context.Context, arg UpdateUserDataParams)
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.NotifyLikes,
+		&i.NotifyReplies,
+		&i.NotifyFollows,
+		&i.NotifyMentions,
 	)
 	return i, err
 }
 
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users 
+    SET hashed_password = $2,
+    updated_at = NOW()
+    WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID             uuid.UUID
+	HashedPassword string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.HashedPassword)
+	return err
+}
+
 const upgradeUserById = `-- name: UpgradeUserById :one
 UPDATE users 
     SET is_chirpy_red = true,
     updated_at = NOW()
     WHERE id = $1
-    RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+    RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, notify_likes, notify_replies, notify_follows, notify_mentions
 `
 
 func (q *Queries) UpgradeUserById(ctx context.Context, id uuid.UUID) (User, error) {
@@ -152,6 +417,14 @@ func (q *Queries) UpgradeUserById(ctx context.Context, id uuid.UUID) (User, erro
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.NotifyLikes,
+		&i.NotifyReplies,
+		&i.NotifyFollows,
+		&i.NotifyMentions,
 	)
 	return i, err
 }
@@ -0,0 +1,90 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxHashtagLength is the longest hashtag in characters, without the # sign.
+const maxHashtagLength = 100
+
+// Hashtag is a #tag found in a text.
+//
+// Tag is the normalized form of the hashtag, without the # sign, see NormalizeHashtag.
+// Start and End are offsets in characters (Unicode code points), not bytes.
+// Start is the offset of the # sign, End is the offset right after the tag.
+type Hashtag struct {
+	Tag   string
+	Start int
+	End   int
+}
+
+// ParseHashtags finds the #hashtags in a text.
+//
+// A hashtag is a # sign followed by letters, digits, combining marks or underscores in any script,
+// with at least one character that is not a digit, so "#1" is not a hashtag.
+// The # sign must not directly follow a letter, a digit, an underscore, a # or an &,
+// so "C#", "##tag" and HTML entities like "&#39;" are not hashtags.
+// Returns the hashtags in the order they appear, a tag used twice is returned twice.
+func ParseHashtags(text string) []Hashtag {
+	runes := []rune(text)
+	hashtags := []Hashtag{}
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' {
+			continue
+		}
+		if i > 0 && (isWordRune(runes[i-1]) || runes[i-1] == '#' || runes[i-1] == '&') {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isHashtagRune(runes[end]) {
+			end++
+		}
+		if !ValidHashtag(string(runes[i+1 : end])) {
+			i = end - 1
+			continue
+		}
+
+		hashtags = append(hashtags, Hashtag{
+			Tag:   NormalizeHashtag(string(runes[i+1 : end])),
+			Start: i,
+			End:   end,
+		})
+		i = end - 1
+	}
+	return hashtags
+}
+
+// ValidHashtag reports whether tag, without the # sign, can be a hashtag.
+func ValidHashtag(tag string) bool {
+	length := 0
+	allDigits := true
+	for _, r := range tag {
+		if !isHashtagRune(r) {
+			return false
+		}
+		if !unicode.IsDigit(r) {
+			allDigits = false
+		}
+		length++
+	}
+	return length > 0 && length <= maxHashtagLength && !allDigits
+}
+
+// NormalizeHashtag returns the form of a hashtag tags are stored and compared in.
+//
+// It applies Unicode simple case folding, so "#Go", "#GO" and "#go" are the same tag,
+// and so are the Greek "#ΟΔΟΣ" and "#οδος", which is spelled with a final sigma.
+func NormalizeHashtag(tag string) string {
+	return strings.Map(func(r rune) rune {
+		// upper then lower case folds letters with several lower case forms, like σ and ς, together
+		return unicode.ToLower(unicode.ToUpper(r))
+	}, tag)
+}
+
+// isHashtagRune reports whether r can be part of a hashtag.
+func isHashtagRune(r rune) bool {
+	return isWordRune(r) || unicode.IsMark(r)
+}
@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseHashtags tests the ParseHashtags function with various test cases.
+//
+// It checks which # signs start a hashtag, the normalized tags and their character offsets.
+func TestParseHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Hashtag
+	}{
+		{
+			name: "No hashtags",
+			text: "just chirping",
+			want: []Hashtag{},
+		},
+		{
+			name: "Single hashtag",
+			text: "learning #Go!",
+			want: []Hashtag{{Tag: "go", Start: 9, End: 12}},
+		},
+		{
+			name: "Non-Latin script",
+			text: "#ΟΔΟΣ #日本語",
+			want: []Hashtag{
+				{Tag: "οδοσ", Start: 0, End: 5},
+				{Tag: "日本語", Start: 6, End: 10},
+			},
+		},
+		{
+			name: "Digits and underscores",
+			text: "#go_1_23",
+			want: []Hashtag{{Tag: "go_1_23", Start: 0, End: 8}},
+		},
+		{
+			name: "Only digits",
+			text: "we're #1",
+			want: []Hashtag{},
+		},
+		{
+			name: "Inside a word",
+			text: "C# and F#",
+			want: []Hashtag{},
+		},
+		{
+			name: "Double hash",
+			text: "##tag",
+			want: []Hashtag{},
+		},
+		{
+			name: "HTML entity",
+			text: "it&#39;s",
+			want: []Hashtag{},
+		},
+		{
+			name: "Lone hash",
+			text: "# heading",
+			want: []Hashtag{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseHashtags(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseHashtags(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNormalizeHashtag tests the NormalizeHashtag function with various test cases.
+//
+// It checks that spellings differing only in case map to the same tag.
+func TestNormalizeHashtag(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want string
+	}{
+		{
+			name: "ASCII",
+			tag:  "GoLang",
+			want: "golang",
+		},
+		{
+			name: "Final sigma",
+			tag:  "οδος",
+			want: "οδοσ",
+		},
+		{
+			name: "Long s",
+			tag:  "ſun",
+			want: "sun",
+		},
+		{
+			name: "Kelvin sign",
+			tag:  "Kelvin",
+			want: "kelvin",
+		},
+		{
+			name: "Already normalized",
+			tag:  "日本語",
+			want: "日本語",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeHashtag(tt.tag)
+			if got != tt.want {
+				t.Errorf("NormalizeHashtag(%q) = %q, want %q", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Package entities extracts structured entities, like @mentions, from the body of chirps.
+package entities
+
+import (
+	"unicode"
+)
+
+const (
+	minHandleLength = 3
+	maxHandleLength = 15
+)
+
+// Mention is an @handle found in a text.
+//
+// Start and End are offsets in characters (Unicode code points), not bytes.
+// Start is the offset of the @ sign, End is the offset right after the handle.
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// ParseMentions finds the @handle mentions in a text.
+//
+// A mention is an @ sign followed by 3 to 15 ASCII letters, digits or underscores,
+// the same characters a handle may have. The @ sign must not directly follow a letter, a digit or
+// an underscore, so email addresses are not mentions, and a longer run of handle characters is not
+// a mention of its first 15 characters.
+// Returns the mentions in the order they appear, a handle mentioned twice is returned twice.
+func ParseMentions(text string) []Mention {
+	runes := []rune(text)
+	mentions := []Mention{}
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' {
+			continue
+		}
+		if i > 0 && (isWordRune(runes[i-1]) || runes[i-1] == '@') {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isHandleRune(runes[end]) {
+			end++
+		}
+		length := end - i - 1
+		if length < minHandleLength || length > maxHandleLength {
+			i = end - 1
+			continue
+		}
+		// a handle running into other letters, like "@bobé", is not a mention of bob
+		if end < len(runes) && (isWordRune(runes[end]) || runes[end] == '@') {
+			i = end - 1
+			continue
+		}
+
+		mentions = append(mentions, Mention{
+			Handle: string(runes[i+1 : end]),
+			Start:  i,
+			End:    end,
+		})
+		i = end - 1
+	}
+	return mentions
+}
+
+// isHandleRune reports whether r can be part of a handle.
+func isHandleRune(r rune) bool {
+	return r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
+
+// isWordRune reports whether r is part of a word, in any script.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
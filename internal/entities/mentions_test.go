@@ -0,0 +1,88 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseMentions tests the ParseMentions function with various test cases.
+//
+// It checks which @ signs start a mention and the character offsets of the mentions.
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Mention
+	}{
+		{
+			name: "No mentions",
+			text: "just chirping",
+			want: []Mention{},
+		},
+		{
+			name: "Single mention",
+			text: "hi @bob_99!",
+			want: []Mention{{Handle: "bob_99", Start: 3, End: 10}},
+		},
+		{
+			name: "Mention at the start",
+			text: "@alice hello",
+			want: []Mention{{Handle: "alice", Start: 0, End: 6}},
+		},
+		{
+			name: "Repeated mention",
+			text: "@bob and @bob",
+			want: []Mention{
+				{Handle: "bob", Start: 0, End: 4},
+				{Handle: "bob", Start: 9, End: 13},
+			},
+		},
+		{
+			name: "Offsets count characters, not bytes",
+			text: "héllo @bob",
+			want: []Mention{{Handle: "bob", Start: 6, End: 10}},
+		},
+		{
+			name: "Email address",
+			text: "mail bob@example.com",
+			want: []Mention{},
+		},
+		{
+			name: "Too short",
+			text: "@ab",
+			want: []Mention{},
+		},
+		{
+			name: "Too long",
+			text: "@abcdefghijklmnop",
+			want: []Mention{},
+		},
+		{
+			name: "Followed by a non-ASCII letter",
+			text: "@bobé",
+			want: []Mention{},
+		},
+		{
+			name: "Double at sign",
+			text: "@@bob",
+			want: []Mention{},
+		},
+		{
+			name: "Punctuation around the mention",
+			text: "(@bob), @carol.",
+			want: []Mention{
+				{Handle: "bob", Start: 1, End: 5},
+				{Handle: "carol", Start: 8, End: 14},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseMentions(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMentions(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogMailer is a Mailer writing the messages to an io.Writer instead of sending them,
+// like a file or the log, for development and tests.
+type LogMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+// NewLogMailer creates a LogMailer.
+//
+// It takes the writer the messages are written to and the address they are from.
+// Returns the LogMailer.
+func NewLogMailer(w io.Writer, from string) *LogMailer {
+	return &LogMailer{
+		w:    w,
+		from: from,
+	}
+}
+
+// Send writes a message as it would be sent, each message is followed by a blank line.
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	formatted, err := format(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err = m.w.Write(append(formatted, "\r\n"...))
+	return err
+}
@@ -0,0 +1,79 @@
+// Package mailer sends plain text emails, like password reset links.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// ErrInvalidMessage is returned for a message without a valid recipient, or with a line break in its subject.
+var ErrInvalidMessage = errors.New("invalid email message")
+
+// Message is a plain text email to a single recipient.
+type Message struct {
+	// To is the address of the recipient, like "alice@example.com".
+	To string
+	// Subject is the subject line, which may contain any UTF-8 text but no line breaks.
+	Subject string
+	// Body is the plain text content.
+	Body string
+}
+
+// Mailer sends emails.
+type Mailer interface {
+	// Send delivers a message, or hands it over to a server that delivers it.
+	Send(ctx context.Context, msg Message) error
+}
+
+// validate checks that a message can be sent, so its fields can't inject headers.
+func (msg Message) validate() error {
+	address, err := mail.ParseAddress(msg.To)
+	if err != nil || address.Address != msg.To {
+		return fmt.Errorf("%w: invalid recipient %q", ErrInvalidMessage, msg.To)
+	}
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("%w: line break in the subject", ErrInvalidMessage)
+	}
+	return nil
+}
+
+// format renders a message in the Internet Message Format of RFC 5322.
+//
+// It takes the address of the sender, the message and the date of the message.
+// The subject is encoded for non-ASCII text and the body is quoted-printable, so any UTF-8 text goes through.
+// Returns the message, or an error wrapping ErrInvalidMessage if it can't be sent.
+func format(from string, msg Message, date time.Time) ([]byte, error) {
+	err := msg.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "From: %s\r\n", from)
+	fmt.Fprintf(buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(buf)
+	_, err = body.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n")))
+	if err != nil {
+		return nil, err
+	}
+	err = body.Close()
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString("\r\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,217 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readBody parses a formatted message and decodes its quoted-printable body.
+func readBody(t *testing.T, formatted []byte) (*mail.Message, string) {
+	t.Helper()
+	parsed, err := mail.ReadMessage(bytes.NewReader(formatted))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if err != nil {
+		t.Fatalf("reading the body: %v", err)
+	}
+	return parsed, string(body)
+}
+
+// TestFormat tests the rendering of messages and the refusal of those that could inject headers.
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		msg         Message
+		wantSubject string
+		wantBody    string
+		wantErr     error
+	}{
+		{
+			name:        "ASCII message",
+			msg:         Message{To: "alice@example.com", Subject: "Reset your password", Body: "Hello\nBye"},
+			wantSubject: "Reset your password",
+			wantBody:    "Hello\r\nBye\r\n",
+		},
+		{
+			name:        "UTF-8 message",
+			msg:         Message{To: "alice@example.com", Subject: "Jelszó visszaállítása", Body: "Szia, ő itt 🐦"},
+			wantSubject: "Jelszó visszaállítása",
+			wantBody:    "Szia, ő itt 🐦\r\n",
+		},
+		{
+			name:    "Header in the recipient",
+			msg:     Message{To: "alice@example.com\r\nBcc: eve@example.com", Subject: "Hi"},
+			wantErr: ErrInvalidMessage,
+		},
+		{
+			name:    "Recipient with a display name",
+			msg:     Message{To: "Alice <alice@example.com>", Subject: "Hi"},
+			wantErr: ErrInvalidMessage,
+		},
+		{
+			name:    "Header in the subject",
+			msg:     Message{To: "alice@example.com", Subject: "Hi\r\nBcc: eve@example.com"},
+			wantErr: ErrInvalidMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted, err := format("chirpy@example.com", tt.msg, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("format() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			parsed, body := readBody(t, formatted)
+			subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+			if err != nil {
+				t.Fatalf("DecodeHeader() error = %v", err)
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", subject, tt.wantSubject)
+			}
+			if got := parsed.Header.Get("To"); got != tt.msg.To {
+				t.Errorf("To = %q, want %q", got, tt.msg.To)
+			}
+			if got := parsed.Header.Get("From"); got != "chirpy@example.com" {
+				t.Errorf("From = %q, want %q", got, "chirpy@example.com")
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+// TestLogMailer tests that a LogMailer writes the messages it sends, one after the other.
+func TestLogMailer(t *testing.T) {
+	buf := &bytes.Buffer{}
+	mailer := NewLogMailer(buf, "chirpy@example.com")
+
+	for _, to := range []string{"alice@example.com", "bob@example.com"} {
+		err := mailer.Send(context.Background(), Message{To: to, Subject: "Hi", Body: "Hello"})
+		if err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	err := mailer.Send(context.Background(), Message{To: "not an address", Subject: "Hi"})
+	if !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Send() error = %v, want %v", err, ErrInvalidMessage)
+	}
+
+	written := buf.String()
+	if got := strings.Count(written, "From: chirpy@example.com\r\n"); got != 2 {
+		t.Errorf("wrote %d messages, want 2", got)
+	}
+	if !strings.Contains(written, "To: alice@example.com\r\n") || !strings.Contains(written, "To: bob@example.com\r\n") {
+		t.Errorf("written messages miss a recipient:\n%s", written)
+	}
+}
+
+// fakeSMTPServer accepts a single SMTP session on localhost, offering PLAIN authentication.
+// It returns the port it listens on, and a channel receiving the commands and the message of the session.
+func fakeSMTPServer(t *testing.T) (string, <-chan []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	session := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		received := []string{}
+		reader := bufio.NewReader(conn)
+		io.WriteString(conn, "220 localhost ESMTP\r\n")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				session <- received
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			received = append(received, line)
+			switch command {
+			case "EHLO":
+				io.WriteString(conn, "250-localhost\r\n250 AUTH PLAIN\r\n")
+			case "AUTH":
+				io.WriteString(conn, "235 2.7.0 Authentication successful\r\n")
+			case "DATA":
+				io.WriteString(conn, "354 End data with <CR><LF>.<CR><LF>\r\n")
+				data := []string{}
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data = append(data, line)
+				}
+				received = append(received, strings.Join(data, ""))
+				io.WriteString(conn, "250 OK\r\n")
+			case "QUIT":
+				io.WriteString(conn, "221 Bye\r\n")
+				session <- received
+				return
+			default:
+				io.WriteString(conn, "250 OK\r\n")
+			}
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	return port, session
+}
+
+// TestSMTPMailer tests the SMTP session of a delivery.
+func TestSMTPMailer(t *testing.T) {
+	port, session := fakeSMTPServer(t)
+	mailer := NewSMTPMailer("127.0.0.1", port, "chirpy", "secret", "chirpy@example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := mailer.Send(ctx, Message{To: "alice@example.com", Subject: "Hi", Body: "Hello"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	received := <-session
+	wantPrefixes := []string{"EHLO", "AUTH PLAIN", "MAIL FROM:<chirpy@example.com>", "RCPT TO:<alice@example.com>", "DATA", "From:", "QUIT"}
+	if len(received) != len(wantPrefixes) {
+		t.Fatalf("session = %q, want commands %q", received, wantPrefixes)
+	}
+	for i, prefix := range wantPrefixes {
+		if !strings.HasPrefix(received[i], prefix) {
+			t.Errorf("session[%d] = %q, want prefix %q", i, received[i], prefix)
+		}
+	}
+
+	_, body := readBody(t, []byte(received[5]))
+	if body != "Hello\r\n" {
+		t.Errorf("body = %q, want %q", body, "Hello\r\n")
+	}
+}
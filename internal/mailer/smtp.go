@@ -0,0 +1,105 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// defaultSMTPTimeout bounds a delivery when the context has no deadline.
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPMailer is a Mailer handing the messages over to an SMTP server.
+// It upgrades the connection with STARTTLS when the server offers it, and authenticates when given credentials.
+type SMTPMailer struct {
+	addr     string
+	host     string
+	from     string
+	username string
+	password string
+}
+
+// NewSMTPMailer creates an SMTPMailer.
+//
+// It takes the host and port of the server, the username and password to authenticate with,
+// both empty for a server that doesn't require it, and the address the messages are from.
+// PLAIN authentication is only used over TLS, or with a server on localhost.
+// Returns the SMTPMailer.
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		from:     from,
+		username: username,
+		password: password,
+	}
+}
+
+// Send delivers a message to the SMTP server, within the deadline of the context.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	formatted, err := format(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultSMTPTimeout)
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	// net/smtp doesn't take a context, the deadline of the connection bounds the whole exchange
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: m.host})
+		if err != nil {
+			return err
+		}
+	}
+	if m.username != "" {
+		err = client.Auth(smtp.PlainAuth("", m.username, m.password, m.host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(m.from)
+	if err != nil {
+		return err
+	}
+	err = client.Rcpt(msg.To)
+	if err != nil {
+		return err
+	}
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = data.Write(formatted)
+	if err != nil {
+		data.Close()
+		return err
+	}
+	err = data.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
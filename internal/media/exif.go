@@ -0,0 +1,126 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+const (
+	markerSOI  = 0xD8
+	markerSOS  = 0xDA
+	markerAPP1 = 0xE1
+
+	tagOrientation = 0x0112
+)
+
+// jpegOrientation reads the EXIF orientation of a JPEG image.
+//
+// It takes the content of the JPEG file.
+// Returns the orientation, from 1 to 8 as defined by EXIF, or 1 (upright) if the image doesn't have a valid one.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != markerSOI {
+		return 1
+	}
+
+	// walk the segments before the image data, looking for the EXIF one
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == markerSOS {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == markerAPP1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first IFD of EXIF data in TIFF format.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != tagOrientation {
+			continue
+		}
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		if orientation < 1 || orientation > 8 {
+			return 1
+		}
+		return orientation
+	}
+	return 1
+}
+
+// orient turns an image upright according to its EXIF orientation.
+//
+// It takes the image as stored and its orientation.
+// Orientations 5 to 8 swap the width and the height.
+// Returns the upright image, the image itself for orientation 1.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			// the pixel of the stored image that ends up at (x, y)
+			var sx, sy int
+			switch orientation {
+			case 2: // needs mirroring
+				sx, sy = width-1-x, y
+			case 3: // needs rotating 180°
+				sx, sy = width-1-x, height-1-y
+			case 4: // needs flipping upside down
+				sx, sy = x, height-1-y
+			case 5: // needs mirroring and rotating 90° counter-clockwise
+				sx, sy = y, x
+			case 6: // needs rotating 90° clockwise
+				sx, sy = y, height-1-x
+			case 7: // needs mirroring and rotating 90° clockwise
+				sx, sy = width-1-y, height-1-x
+			case 8: // needs rotating 90° counter-clockwise
+				sx, sy = width-1-y, x
+			}
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], img.Pix[sy*img.Stride+sx*4:])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,168 @@
+// Package media checks uploaded images, strips their metadata and makes their thumbnails, in pure Go.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	// MaxFileSize is the largest image file accepted, in bytes.
+	MaxFileSize = 5 << 20
+	// MaxPixels is the largest image accepted, in pixels, it guards against small files that decode to huge images.
+	// An image of 4096 x 4096 pixels takes 64 MB once decoded.
+	MaxPixels = 4096 * 4096
+	// ThumbnailSize is the longest side of thumbnails, in pixels.
+	ThumbnailSize = 400
+
+	jpegQuality = 90
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type, only JPEG and PNG are accepted")
+	ErrTooLarge        = errors.New("image is too large")
+	ErrInvalidImage    = errors.New("invalid image")
+)
+
+// Image is an uploaded image re-encoded without its metadata, together with its thumbnail.
+type Image struct {
+	ContentType     string
+	Data            []byte
+	Width           int
+	Height          int
+	Thumbnail       []byte
+	ThumbnailWidth  int
+	ThumbnailHeight int
+}
+
+// Process checks an uploaded image and prepares it for storage.
+//
+// It takes the content of the uploaded file, its type is sniffed from the content, the declared type is not trusted.
+// The image is decoded and encoded again in the same format, which drops EXIF and every other metadata
+// (like the location a photo was taken at). The EXIF orientation of JPEG images is applied to the pixels first,
+// so photos still show the right way up.
+// Returns the re-encoded image with a thumbnail no larger than ThumbnailSize on either side,
+// or ErrTooLarge, ErrUnsupportedType or ErrInvalidImage.
+func Process(data []byte) (Image, error) {
+	if len(data) > MaxFileSize {
+		return Image{}, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return Image{}, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return Image{}, ErrInvalidImage
+	}
+	if config.Width*config.Height > MaxPixels {
+		return Image{}, ErrTooLarge
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	img := toRGBA(decoded)
+	if contentType == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	encoded, err := encode(img, contentType)
+	if err != nil {
+		return Image{}, err
+	}
+	thumbnail := Thumbnail(img, ThumbnailSize)
+	encodedThumbnail, err := encode(thumbnail, contentType)
+	if err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		ContentType:     contentType,
+		Data:            encoded,
+		Width:           img.Bounds().Dx(),
+		Height:          img.Bounds().Dy(),
+		Thumbnail:       encodedThumbnail,
+		ThumbnailWidth:  thumbnail.Bounds().Dx(),
+		ThumbnailHeight: thumbnail.Bounds().Dy(),
+	}, nil
+}
+
+// Thumbnail scales an image down to fit in a square.
+//
+// It takes the image and the side of the square in pixels.
+// Every pixel of the thumbnail is the average of the pixels of the image it covers, which keeps fine detail from aliasing.
+// Returns the image itself if it already fits.
+func Thumbnail(img *image.RGBA, size int) *image.RGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	thumbWidth, thumbHeight := size, size
+	if width > height {
+		thumbHeight = max(1, height*size/width)
+	} else {
+		thumbWidth = max(1, width*size/height)
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for ty := 0; ty < thumbHeight; ty++ {
+		y0, y1 := ty*height/thumbHeight, max((ty+1)*height/thumbHeight, ty*height/thumbHeight+1)
+		for tx := 0; tx < thumbWidth; tx++ {
+			x0, x1 := tx*width/thumbWidth, max((tx+1)*width/thumbWidth, tx*width/thumbWidth+1)
+
+			var sum [4]int
+			for y := y0; y < y1; y++ {
+				row := img.Pix[y*img.Stride:]
+				for x := x0; x < x1; x++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[x*4+c])
+					}
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			offset := ty*thumb.Stride + tx*4
+			for c := 0; c < 4; c++ {
+				thumb.Pix[offset+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return thumb
+}
+
+// toRGBA copies an image into an RGBA image with its origin at (0, 0).
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// encode encodes an image as JPEG or PNG.
+func encode(img image.Image, contentType string) ([]byte, error) {
+	buf := bytes.Buffer{}
+	var err error
+	if contentType == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
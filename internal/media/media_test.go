@@ -0,0 +1,190 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// testImage returns a width x height image, red in its top left pixel and blue elsewhere.
+func testImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{B: 255, A: 255})
+		}
+	}
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	return img
+}
+
+// exifSegment returns an APP1 segment holding EXIF data with only an orientation tag, in big endian order.
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, tagOrientation)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, markerAPP1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// pngHeader returns the start of a PNG file declaring a width x height image, without any pixel data.
+func pngHeader(width, height uint32) []byte {
+	chunk := []byte("IHDR")
+	chunk = binary.BigEndian.AppendUint32(chunk, width)
+	chunk = binary.BigEndian.AppendUint32(chunk, height)
+	chunk = append(chunk, 8, 6, 0, 0, 0) // 8-bit RGBA, no interlacing
+
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(chunk)-4))
+	data = append(data, chunk...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(chunk))
+}
+
+// jpegWithExif encodes an image as JPEG and inserts an EXIF segment right after the start of image marker.
+func jpegWithExif(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), exifSegment(orientation)...), data[2:]...)
+}
+
+// TestProcess tests the Process function with various test cases.
+//
+// It checks the sniffed content type, the dimensions after orientation and thumbnailing, and the errors.
+func TestProcess(t *testing.T) {
+	pngData := bytes.Buffer{}
+	if err := png.Encode(&pngData, testImage(800, 400)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		data            []byte
+		wantType        string
+		wantWidth       int
+		wantHeight      int
+		wantThumbWidth  int
+		wantThumbHeight int
+		wantErr         error
+	}{
+		{
+			name:            "PNG larger than a thumbnail",
+			data:            pngData.Bytes(),
+			wantType:        "image/png",
+			wantWidth:       800,
+			wantHeight:      400,
+			wantThumbWidth:  400,
+			wantThumbHeight: 200,
+		},
+		{
+			name:            "Upright JPEG",
+			data:            jpegWithExif(t, testImage(40, 20), 1),
+			wantType:        "image/jpeg",
+			wantWidth:       40,
+			wantHeight:      20,
+			wantThumbWidth:  40,
+			wantThumbHeight: 20,
+		},
+		{
+			name:            "Rotated JPEG",
+			data:            jpegWithExif(t, testImage(40, 20), 6),
+			wantType:        "image/jpeg",
+			wantWidth:       20,
+			wantHeight:      40,
+			wantThumbWidth:  20,
+			wantThumbHeight: 40,
+		},
+		{
+			name:    "GIF",
+			data:    []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"),
+			wantErr: ErrUnsupportedType,
+		},
+		{
+			name:    "Text",
+			data:    []byte("definitely not an image"),
+			wantErr: ErrUnsupportedType,
+		},
+		{
+			name:    "Truncated PNG",
+			data:    pngData.Bytes()[:40],
+			wantErr: ErrInvalidImage,
+		},
+		{
+			name:    "Too large file",
+			data:    make([]byte, MaxFileSize+1),
+			wantErr: ErrTooLarge,
+		},
+		{
+			name:    "Too many pixels",
+			data:    pngHeader(4097, 4096),
+			wantErr: ErrTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Process(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.ContentType != tt.wantType {
+				t.Errorf("Process() ContentType = %v, want %v", got.ContentType, tt.wantType)
+			}
+			if got.Width != tt.wantWidth || got.Height != tt.wantHeight {
+				t.Errorf("Process() size = %dx%d, want %dx%d", got.Width, got.Height, tt.wantWidth, tt.wantHeight)
+			}
+			if got.ThumbnailWidth != tt.wantThumbWidth || got.ThumbnailHeight != tt.wantThumbHeight {
+				t.Errorf("Process() thumbnail size = %dx%d, want %dx%d", got.ThumbnailWidth, got.ThumbnailHeight, tt.wantThumbWidth, tt.wantThumbHeight)
+			}
+			if bytes.Contains(got.Data, []byte("Exif")) {
+				t.Errorf("Process() kept the EXIF data")
+			}
+		})
+	}
+}
+
+// TestOrient tests the orient function with various test cases.
+//
+// It follows the top left pixel of the stored image to where it is displayed.
+func TestOrient(t *testing.T) {
+	tests := []struct {
+		orientation int
+		wantX       int
+		wantY       int
+	}{
+		{orientation: 1, wantX: 0, wantY: 0},
+		{orientation: 2, wantX: 3, wantY: 0},
+		{orientation: 3, wantX: 3, wantY: 1},
+		{orientation: 4, wantX: 0, wantY: 1},
+		{orientation: 5, wantX: 0, wantY: 0},
+		{orientation: 6, wantX: 1, wantY: 0},
+		{orientation: 7, wantX: 1, wantY: 3},
+		{orientation: 8, wantX: 0, wantY: 3},
+	}
+
+	for _, tt := range tests {
+		got := orient(testImage(4, 2), tt.orientation)
+		if r, _, _, _ := got.At(tt.wantX, tt.wantY).RGBA(); r == 0 {
+			t.Errorf("orient(%d) didn't move the top left pixel to (%d, %d)", tt.orientation, tt.wantX, tt.wantY)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package realtime delivers events to the clients connected to the server as they happen.
+package realtime
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrClosed is returned by Subscribe once the hub is closed.
+var ErrClosed = errors.New("realtime hub is closed")
+
+// Event is something that happened which connected clients are told about.
+type Event struct {
+	// ID is assigned by the hub when the event is published, later events have greater IDs.
+	ID uint64
+	// Type names the event, like "chirp" or "notification".
+	Type string
+	// UserID is the only user the event is for, uuid.Nil for an event for everyone.
+	UserID uuid.UUID
+	// ActorIDs are the users whose actions or chirps the event carries, like the author of a rechirp and of the chirp it reposts,
+	// so subscribers can leave out the events of the users they don't want to see.
+	ActorIDs []uuid.UUID
+	// Topics are what the event is about, like "feed" or "author:<id>", so subscribers can pick the events they follow.
+	// The hub delivers an event whatever its topics, picking is up to the subscriber.
+	Topics []string
+	// Data is the payload of the event, usually JSON.
+	Data []byte
+}
+
+// Hub is an in-process publish/subscribe hub. It keeps the latest events,
+// so a subscriber that lost its connection can resume after the last event it received.
+// A Hub is safe for concurrent use.
+type Hub struct {
+	mu         sync.Mutex
+	nextID     uint64
+	history    []Event
+	oldest     int
+	bufferSize int
+	subs       map[*Subscription]struct{}
+	closed     bool
+}
+
+// Subscription receives the events published to a hub for one user.
+type Subscription struct {
+	// C delivers the events. It is closed when the subscription ends: when it is closed,
+	// when the hub is closed, or when the subscriber falls more than the buffer size behind.
+	C <-chan Event
+
+	hub    *Hub
+	ch     chan Event
+	userID uuid.UUID
+}
+
+// NewHub creates a Hub.
+//
+// It takes the number of events kept for resuming subscribers and the number of events
+// a subscriber may fall behind before it is dropped.
+// The IDs start from the current time, so the IDs of a restarted server don't repeat the IDs handed out before.
+// Returns the Hub.
+func NewHub(historySize, bufferSize int) *Hub {
+	return &Hub{
+		nextID:     uint64(time.Now().UnixNano()),
+		history:    make([]Event, 0, historySize),
+		bufferSize: bufferSize,
+		subs:       map[*Subscription]struct{}{},
+	}
+}
+
+// Publish sends an event to every subscriber it is for.
+//
+// It takes the event, its ID is assigned by the hub.
+// Publish never waits for a subscriber: one that has fallen bufferSize events behind is dropped,
+// and may resume from the history by subscribing again.
+// Returns the event with its ID.
+func (h *Hub) Publish(event Event) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	event.ID = h.nextID
+	if cap(h.history) > 0 {
+		if len(h.history) < cap(h.history) {
+			h.history = append(h.history, event)
+		} else {
+			h.history[h.oldest] = event
+			h.oldest = (h.oldest + 1) % len(h.history)
+		}
+	}
+
+	for sub := range h.subs {
+		if !sub.wants(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			h.drop(sub)
+		}
+	}
+	return event
+}
+
+// Subscribe starts delivering the events for a user.
+//
+// It takes the ID of the user and the ID of the last event the subscriber received, 0 for a new subscriber.
+// The kept events after lastEventID are delivered first. Events that are no longer kept are lost.
+// Returns the Subscription, or ErrClosed if the hub is closed.
+func (h *Hub) Subscribe(userID uuid.UUID, lastEventID uint64) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+
+	sub := &Subscription{
+		hub:    h,
+		userID: userID,
+	}
+	missed := []Event{}
+	if lastEventID != 0 {
+		for i := range h.history {
+			event := h.history[(h.oldest+i)%len(h.history)]
+			if event.ID > lastEventID && sub.wants(event) {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	// the missed events don't count against the buffer
+	sub.ch = make(chan Event, len(missed)+h.bufferSize)
+	for _, event := range missed {
+		sub.ch <- event
+	}
+	sub.C = sub.ch
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Close ends every subscription and refuses new ones.
+// It is meant to be called when the server shuts down, so long-lived connections end.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.drop(sub)
+	}
+}
+
+// drop ends a subscription. The caller must hold h.mu.
+func (h *Hub) drop(sub *Subscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.ch)
+}
+
+// Close ends the subscription, closing it again does nothing.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.drop(s)
+}
+
+// wants reports whether an event is for the user of the subscription.
+func (s *Subscription) wants(event Event) bool {
+	return event.UserID == uuid.Nil || event.UserID == s.userID
+}
@@ -0,0 +1,180 @@
+package realtime
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// drain collects the events waiting on a subscription without blocking.
+func drain(sub *Subscription) (types []string, open bool) {
+	types = []string{}
+	for {
+		select {
+		case event, ok := <-sub.C:
+			if !ok {
+				return types, false
+			}
+			types = append(types, event.Type)
+		default:
+			return types, true
+		}
+	}
+}
+
+// TestPublish tests which subscribers receive a published event.
+func TestPublish(t *testing.T) {
+	alice := uuid.New()
+	bob := uuid.New()
+
+	tests := []struct {
+		name      string
+		event     Event
+		wantAlice []string
+		wantBob   []string
+	}{
+		{
+			name:      "Event for everyone",
+			event:     Event{Type: "chirp"},
+			wantAlice: []string{"chirp"},
+			wantBob:   []string{"chirp"},
+		},
+		{
+			name:      "Event for one user",
+			event:     Event{Type: "notification", UserID: alice},
+			wantAlice: []string{"notification"},
+			wantBob:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(10, 10)
+			aliceSub, err := hub.Subscribe(alice, 0)
+			if err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+			bobSub, err := hub.Subscribe(bob, 0)
+			if err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+
+			hub.Publish(tt.event)
+
+			if got, _ := drain(aliceSub); !reflect.DeepEqual(got, tt.wantAlice) {
+				t.Errorf("alice received %v, want %v", got, tt.wantAlice)
+			}
+			if got, _ := drain(bobSub); !reflect.DeepEqual(got, tt.wantBob) {
+				t.Errorf("bob received %v, want %v", got, tt.wantBob)
+			}
+		})
+	}
+}
+
+// TestSubscribeResume tests that a subscriber resuming after an event receives the kept events after it.
+func TestSubscribeResume(t *testing.T) {
+	alice := uuid.New()
+	hub := NewHub(3, 10)
+	ids := []uint64{}
+	for _, eventType := range []string{"a", "b", "c", "d"} {
+		ids = append(ids, hub.Publish(Event{Type: eventType}).ID)
+	}
+	hub.Publish(Event{Type: "other", UserID: uuid.New()})
+
+	tests := []struct {
+		name        string
+		lastEventID uint64
+		want        []string
+	}{
+		{
+			name:        "New subscriber",
+			lastEventID: 0,
+			want:        []string{},
+		},
+		{
+			name:        "Resume after a kept event",
+			lastEventID: ids[2],
+			want:        []string{"d"},
+		},
+		{
+			name:        "Resume after the latest event",
+			lastEventID: ids[3],
+			want:        []string{},
+		},
+		{
+			name:        "Resume after an event no longer kept",
+			lastEventID: ids[0],
+			want:        []string{"c", "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := hub.Subscribe(alice, tt.lastEventID)
+			if err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+			defer sub.Close()
+
+			if got, _ := drain(sub); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("received %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSlowSubscriber tests that a subscriber falling behind is dropped without holding up the others.
+func TestSlowSubscriber(t *testing.T) {
+	hub := NewHub(10, 2)
+	slow, err := hub.Subscribe(uuid.New(), 0)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	fast, err := hub.Subscribe(uuid.New(), 0)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	received := []string{}
+	for _, eventType := range []string{"a", "b", "c"} {
+		hub.Publish(Event{Type: eventType})
+		got, open := drain(fast)
+		if !open {
+			t.Fatalf("fast subscriber was dropped")
+		}
+		received = append(received, got...)
+	}
+
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(received, want) {
+		t.Errorf("fast subscriber received %v, want %v", received, want)
+	}
+	got, open := drain(slow)
+	if open {
+		t.Errorf("slow subscriber is still open")
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("slow subscriber received %v, want %v", got, want)
+	}
+}
+
+// TestClose tests that closing the hub ends the subscriptions and refuses new ones.
+func TestClose(t *testing.T) {
+	hub := NewHub(10, 10)
+	sub, err := hub.Subscribe(uuid.New(), 0)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	hub.Close()
+
+	if _, open := drain(sub); open {
+		t.Errorf("subscription is still open after Close()")
+	}
+	sub.Close()
+	if _, err := hub.Subscribe(uuid.New(), 0); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe() error = %v, want %v", err, ErrClosed)
+	}
+	hub.Publish(Event{Type: "chirp"})
+}
@@ -0,0 +1,61 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// MaxRelayPayload is the longest relayed event, in bytes, the limit of a Postgres NOTIFY payload.
+const MaxRelayPayload = 7999
+
+// ErrRelayPayloadTooLong is returned by EncodeRelay for an event longer than MaxRelayPayload once encoded.
+var ErrRelayPayloadTooLong = errors.New("relayed event is too long")
+
+// Relay is an event on its way from the instance that published it to the other instances.
+// It carries the ID of what the event is about rather than its data, which can be too long for a NOTIFY payload,
+// the instances receiving it look the data up again.
+type Relay struct {
+	// Origin is the ID of the instance that published the event, so it can leave out its own events.
+	Origin uuid.UUID `json:"origin"`
+	// Type, UserID, ActorIDs and Topics are those of the event, see Event.
+	Type     string      `json:"type"`
+	UserID   uuid.UUID   `json:"user_id"`
+	ActorIDs []uuid.UUID `json:"actor_ids,omitempty"`
+	Topics   []string    `json:"topics,omitempty"`
+	// SubjectID is the ID of what the event is about, its meaning depends on the type.
+	SubjectID uuid.UUID `json:"subject_id"`
+}
+
+// EncodeRelay encodes an event for the other instances of the server.
+//
+// It takes the relayed event.
+// Returns the payload, or an error if the event can't be encoded or is too long to relay.
+func EncodeRelay(relay Relay) ([]byte, error) {
+	payload, err := json.Marshal(relay)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > MaxRelayPayload {
+		return nil, fmt.Errorf("%w: %d bytes", ErrRelayPayloadTooLong, len(payload))
+	}
+	return payload, nil
+}
+
+// DecodeRelay decodes an event relayed by EncodeRelay.
+//
+// It takes the payload.
+// Returns the relayed event, or an error if the payload isn't a relayed event.
+func DecodeRelay(payload []byte) (Relay, error) {
+	relay := Relay{}
+	err := json.Unmarshal(payload, &relay)
+	if err != nil {
+		return Relay{}, err
+	}
+	if relay.Origin == uuid.Nil || relay.Type == "" {
+		return Relay{}, errors.New("relayed event without an origin or a type")
+	}
+	return relay, nil
+}
@@ -0,0 +1,131 @@
+package realtime
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestRelayRoundTrip tests that a relayed event decodes to what was encoded.
+func TestRelayRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		relay Relay
+	}{
+		{
+			name: "Event for everyone",
+			relay: Relay{
+				Origin:    uuid.New(),
+				Type:      "chirp",
+				ActorIDs:  []uuid.UUID{uuid.New()},
+				Topics:    []string{"feed", "author:1"},
+				SubjectID: uuid.New(),
+			},
+		},
+		{
+			name: "Event for one user",
+			relay: Relay{
+				Origin:    uuid.New(),
+				Type:      "notification",
+				UserID:    uuid.New(),
+				ActorIDs:  []uuid.UUID{uuid.New()},
+				Topics:    []string{"notifications"},
+				SubjectID: uuid.New(),
+			},
+		},
+		{
+			name:  "Event about nothing in particular",
+			relay: Relay{Origin: uuid.New(), Type: "content_filter_changed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := EncodeRelay(tt.relay)
+			if err != nil {
+				t.Fatalf("EncodeRelay() error = %v", err)
+			}
+
+			got, err := DecodeRelay(payload)
+			if err != nil {
+				t.Fatalf("DecodeRelay() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.relay) {
+				t.Errorf("DecodeRelay() = %+v, want %+v", got, tt.relay)
+			}
+		})
+	}
+}
+
+// TestEncodeRelay tests which events can't be relayed.
+func TestEncodeRelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		relay   Relay
+		wantErr error
+	}{
+		{
+			name:  "Event that fits",
+			relay: Relay{Origin: uuid.New(), Type: "chirp", Topics: []string{strings.Repeat("a", 7000)}},
+		},
+		{
+			name:    "Event too long for NOTIFY",
+			relay:   Relay{Origin: uuid.New(), Type: "chirp", Topics: []string{strings.Repeat("a", 8000)}},
+			wantErr: ErrRelayPayloadTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := EncodeRelay(tt.relay)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EncodeRelay() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && len(payload) > MaxRelayPayload {
+				t.Errorf("EncodeRelay() payload is %d bytes, want at most %d", len(payload), MaxRelayPayload)
+			}
+		})
+	}
+}
+
+// TestDecodeRelay tests that payloads which aren't relayed events are refused.
+func TestDecodeRelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		wantErr bool
+	}{
+		{
+			name:    "Relayed event",
+			payload: `{"origin":"` + uuid.NewString() + `","type":"chirp","data":{}}`,
+			wantErr: false,
+		},
+		{
+			name:    "Not JSON",
+			payload: "chirp",
+			wantErr: true,
+		},
+		{
+			name:    "Without an origin",
+			payload: `{"type":"chirp","data":{}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Without a type",
+			payload: `{"origin":"` + uuid.NewString() + `","data":{}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeRelay([]byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeRelay() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
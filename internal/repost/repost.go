@@ -0,0 +1,28 @@
+// Package repost resolves which chirp a repost acts on.
+// A rechirp has no content of its own, so rechirping, quoting or undoing the rechirp of a rechirp acts on its original.
+package repost
+
+import (
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// The kinds of reposts, see the repost_kind column of chirps.
+const (
+	KindRechirp = "rechirp"
+	KindQuote   = "quote"
+)
+
+// Target returns the ID of the chirp that reposting a chirp acts on.
+//
+// It takes the chirp as stored. A rechirp stands for its original, any other chirp, quotes included, for itself.
+// Returns the ID, and false if the chirp is a rechirp whose original has been deleted.
+func Target(chirp database.Chirp) (uuid.UUID, bool) {
+	if chirp.RepostKind.String != KindRechirp {
+		return chirp.ID, true
+	}
+	if !chirp.RepostOfID.Valid {
+		return uuid.Nil, false
+	}
+	return chirp.RepostOfID.UUID, true
+}
@@ -0,0 +1,67 @@
+package repost
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// TestTarget tests which chirp a repost of each kind of chirp acts on.
+func TestTarget(t *testing.T) {
+	chirpID := uuid.New()
+	originalID := uuid.New()
+
+	tests := []struct {
+		name   string
+		chirp  database.Chirp
+		want   uuid.UUID
+		wantOK bool
+	}{
+		{
+			name:   "Chirp",
+			chirp:  database.Chirp{ID: chirpID},
+			want:   chirpID,
+			wantOK: true,
+		},
+		{
+			name: "Rechirp",
+			chirp: database.Chirp{
+				ID:         chirpID,
+				RepostKind: sql.NullString{String: KindRechirp, Valid: true},
+				RepostOfID: uuid.NullUUID{UUID: originalID, Valid: true},
+			},
+			want:   originalID,
+			wantOK: true,
+		},
+		{
+			name: "Quote",
+			chirp: database.Chirp{
+				ID:         chirpID,
+				RepostKind: sql.NullString{String: KindQuote, Valid: true},
+				RepostOfID: uuid.NullUUID{UUID: originalID, Valid: true},
+			},
+			want:   chirpID,
+			wantOK: true,
+		},
+		{
+			name: "Rechirp of a deleted chirp",
+			chirp: database.Chirp{
+				ID:         chirpID,
+				RepostKind: sql.NullString{String: KindRechirp, Valid: true},
+			},
+			want:   uuid.Nil,
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Target(tt.chirp)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Target() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package search
+
+import (
+	"errors"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// HighlightStart and HighlightStop are the private use characters the database wraps matches in.
+// They never leave the server, Highlight turns them into <mark> tags.
+const (
+	HighlightStart = '\uE000'
+	HighlightStop  = '\uE001'
+)
+
+// maxTerms caps the number of terms of a single search query.
+const maxTerms = 16
+
+var ErrEmptyQuery = errors.New("search query has no searchable terms")
+var ErrTooManyTerms = errors.New("search query has too many terms")
+
+// BuildQuery converts a user supplied search string into a Postgres tsquery expression.
+//
+// Words are combined with AND. A "quoted phrase" matches the words next to each other,
+// a trailing * turns a word into a prefix match (gopher* matches gophers).
+// Everything except letters and digits is dropped, so the result is always valid to_tsquery input.
+// Returns the tsquery string and an error if no searchable term is left.
+func BuildQuery(q string) (string, error) {
+	terms := []string{}
+
+	for _, token := range tokenize(q) {
+		words := splitWords(token.text)
+		if len(words) == 0 {
+			continue
+		}
+		for i := range words {
+			words[i] = "'" + words[i] + "'"
+		}
+		if token.prefix {
+			words[len(words)-1] += ":*"
+		}
+
+		term := strings.Join(words, " <-> ")
+		if len(words) > 1 {
+			term = "(" + term + ")"
+		}
+		terms = append(terms, term)
+	}
+
+	if len(terms) == 0 {
+		return "", ErrEmptyQuery
+	}
+	if len(terms) > maxTerms {
+		return "", ErrTooManyTerms
+	}
+
+	return strings.Join(terms, " & "), nil
+}
+
+// Highlight turns a snippet produced by the database into HTML.
+//
+// The snippet text is escaped and the matched words are wrapped in <mark> tags.
+func Highlight(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, string(HighlightStart), "<mark>")
+	return strings.ReplaceAll(escaped, string(HighlightStop), "</mark>")
+}
+
+type token struct {
+	text   string
+	prefix bool
+}
+
+// tokenize splits a search string into bare words and quoted phrases.
+func tokenize(q string) []token {
+	tokens := []token{}
+
+	for i, part := range strings.Split(q, "\"") {
+		// every odd part sits between a pair of quotes
+		if i%2 == 1 {
+			tokens = append(tokens, token{text: part})
+			continue
+		}
+		for _, field := range strings.Fields(part) {
+			tokens = append(tokens, token{
+				text:   strings.TrimSuffix(field, "*"),
+				prefix: strings.HasSuffix(field, "*"),
+			})
+		}
+	}
+	return tokens
+}
+
+// splitWords returns the lower cased runs of letters and digits of s.
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
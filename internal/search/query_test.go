@@ -0,0 +1,76 @@
+package search
+
+import (
+	"testing"
+)
+
+// TestBuildQuery tests the BuildQuery function with various test cases.
+//
+// It uses a test struct to define test cases with different search strings and expected tsquery expressions.
+func TestBuildQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Single word",
+			query: "Gopher",
+			want:  "'gopher'",
+		},
+		{
+			name:  "Several words",
+			query: "go gopher",
+			want:  "'go' & 'gopher'",
+		},
+		{
+			name:  "Phrase",
+			query: "\"hello world\" go",
+			want:  "('hello' <-> 'world') & 'go'",
+		},
+		{
+			name:  "Prefix",
+			query: "goph*",
+			want:  "'goph':*",
+		},
+		{
+			name:  "Operators are stripped",
+			query: "a&b | !c:*",
+			want:  "('a' <-> 'b') & 'c':*",
+		},
+		{
+			name:    "Only punctuation",
+			query:   "!!! *",
+			wantErr: true,
+		},
+		{
+			name:    "Empty",
+			query:   "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BuildQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("BuildQuery() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestHighlight tests that Highlight escapes the snippet and marks the matches.
+func TestHighlight(t *testing.T) {
+	snippet := "<b>" + string(HighlightStart) + "gopher" + string(HighlightStop) + "</b>"
+	want := "&lt;b&gt;<mark>gopher</mark>&lt;/b&gt;"
+
+	if got := Highlight(snippet); got != want {
+		t.Errorf("Highlight() got = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,229 @@
+// Package textlength measures texts the way people read them: in user-perceived characters,
+// with links counted at a fixed weight whatever their length.
+package textlength
+
+import (
+	"unicode"
+)
+
+// breakClass is the Grapheme_Cluster_Break property of a rune, see Unicode Standard Annex #29.
+type breakClass int
+
+const (
+	classOther breakClass = iota
+	classCR
+	classLF
+	classControl
+	classExtend
+	classZWJ
+	classRegionalIndicator
+	classPrepend
+	classSpacingMark
+	classL
+	classV
+	classT
+	classLV
+	classLVT
+)
+
+// extendedPictographic holds the emoji and other pictographs that ZWJ sequences are made of.
+// It follows the Extended_Pictographic property closely enough for counting characters.
+var extendedPictographic = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00a9, Hi: 0x00ae, Stride: 5},
+		{Lo: 0x203c, Hi: 0x2049, Stride: 13},
+		{Lo: 0x2122, Hi: 0x2139, Stride: 23},
+		{Lo: 0x2194, Hi: 0x2199, Stride: 1},
+		{Lo: 0x21a9, Hi: 0x21aa, Stride: 1},
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x2328, Hi: 0x2388, Stride: 96},
+		{Lo: 0x23cf, Hi: 0x23cf, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23f3, Stride: 1},
+		{Lo: 0x23f8, Hi: 0x23fa, Stride: 1},
+		{Lo: 0x24c2, Hi: 0x24c2, Stride: 1},
+		{Lo: 0x25aa, Hi: 0x25ab, Stride: 1},
+		{Lo: 0x25b6, Hi: 0x25c0, Stride: 10},
+		{Lo: 0x25fb, Hi: 0x25fe, Stride: 1},
+		{Lo: 0x2600, Hi: 0x27bf, Stride: 1},
+		{Lo: 0x2934, Hi: 0x2935, Stride: 1},
+		{Lo: 0x2b05, Hi: 0x2b07, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b55, Stride: 5},
+		{Lo: 0x3030, Hi: 0x303d, Stride: 13},
+		{Lo: 0x3297, Hi: 0x3299, Stride: 2},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f000, Hi: 0x1f1e5, Stride: 1},
+		{Lo: 0x1f200, Hi: 0x1f3fa, Stride: 1},
+		{Lo: 0x1f400, Hi: 0x1faff, Stride: 1},
+		{Lo: 0x1fc00, Hi: 0x1fffd, Stride: 1},
+	},
+	LatinOffset: 1,
+}
+
+// indicConsonant holds the consonants of the scripts whose conjuncts are single characters:
+// Devanagari, Bengali, Gujarati, Oriya, Telugu and Malayalam, see the Indic_Conjunct_Break property.
+var indicConsonant = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0915, Hi: 0x0939, Stride: 1},
+		{Lo: 0x0958, Hi: 0x095f, Stride: 1},
+		{Lo: 0x0978, Hi: 0x097f, Stride: 1},
+		{Lo: 0x0995, Hi: 0x09a8, Stride: 1},
+		{Lo: 0x09aa, Hi: 0x09b0, Stride: 1},
+		{Lo: 0x09b2, Hi: 0x09b6, Stride: 4},
+		{Lo: 0x09b7, Hi: 0x09b9, Stride: 1},
+		{Lo: 0x09dc, Hi: 0x09dd, Stride: 1},
+		{Lo: 0x09df, Hi: 0x09df, Stride: 1},
+		{Lo: 0x09f0, Hi: 0x09f1, Stride: 1},
+		{Lo: 0x0a95, Hi: 0x0aa8, Stride: 1},
+		{Lo: 0x0aaa, Hi: 0x0ab0, Stride: 1},
+		{Lo: 0x0ab2, Hi: 0x0ab3, Stride: 1},
+		{Lo: 0x0ab5, Hi: 0x0ab9, Stride: 1},
+		{Lo: 0x0af9, Hi: 0x0af9, Stride: 1},
+		{Lo: 0x0b15, Hi: 0x0b28, Stride: 1},
+		{Lo: 0x0b2a, Hi: 0x0b30, Stride: 1},
+		{Lo: 0x0b32, Hi: 0x0b33, Stride: 1},
+		{Lo: 0x0b35, Hi: 0x0b39, Stride: 1},
+		{Lo: 0x0b5c, Hi: 0x0b5d, Stride: 1},
+		{Lo: 0x0b5f, Hi: 0x0b71, Stride: 18},
+		{Lo: 0x0c15, Hi: 0x0c28, Stride: 1},
+		{Lo: 0x0c2a, Hi: 0x0c39, Stride: 1},
+		{Lo: 0x0c58, Hi: 0x0c5a, Stride: 1},
+		{Lo: 0x0d15, Hi: 0x0d3a, Stride: 1},
+	},
+}
+
+// isIndicLinker reports whether a rune is the virama joining the consonants of a conjunct in the scripts of indicConsonant.
+func isIndicLinker(r rune) bool {
+	switch r {
+	case 0x094d, 0x09cd, 0x0acd, 0x0b4d, 0x0c4d, 0x0d4d:
+		return true
+	}
+	return false
+}
+
+// classOf returns the breakClass of a rune.
+func classOf(r rune) breakClass {
+	switch {
+	case r == '\r':
+		return classCR
+	case r == '\n':
+		return classLF
+	case r == 0x200d:
+		return classZWJ
+	case r == 0x200c,
+		r >= 0xff9e && r <= 0xff9f,
+		r >= 0x1f3fb && r <= 0x1f3ff, // skin tone modifiers
+		r >= 0xe0020 && r <= 0xe007f, // tags of subdivision flags
+		unicode.In(r, unicode.Mn, unicode.Me):
+		return classExtend
+	case r >= 0x1f1e6 && r <= 0x1f1ff:
+		return classRegionalIndicator
+	case r >= 0x0600 && r <= 0x0605, r == 0x06dd, r == 0x070f, r == 0x0890, r == 0x0891,
+		r == 0x08e2, r == 0x110bd, r == 0x110cd:
+		return classPrepend
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp):
+		return classControl
+	case unicode.Is(unicode.Mc, r):
+		return classSpacingMark
+	case r >= 0x1100 && r <= 0x115f, r >= 0xa960 && r <= 0xa97c:
+		return classL
+	case r >= 0x1160 && r <= 0x11a7, r >= 0xd7b0 && r <= 0xd7c6:
+		return classV
+	case r >= 0x11a8 && r <= 0x11ff, r >= 0xd7cb && r <= 0xd7fb:
+		return classT
+	case r >= 0xac00 && r <= 0xd7a3:
+		// precomposed syllables, every 28th one has no final consonant
+		if (r-0xac00)%28 == 0 {
+			return classLV
+		}
+		return classLVT
+	}
+	return classOther
+}
+
+// Graphemes counts the user-perceived characters (extended grapheme clusters) of a text.
+//
+// An emoji with a skin tone, a family joined with zero width joiners, a flag, a letter with
+// combining accents, a Hangul syllable written as separate jamo and an Indic conjunct each count as one character.
+// Returns the number of characters, 0 for an empty text.
+func Graphemes(text string) int {
+	count := 0
+	prev := classOther
+	// pictographic is set while the current character is a pictograph followed by extends and a joiner
+	pictographic := false
+	// regionalIndicators counts the regional indicators in a row, a flag is a pair of them
+	regionalIndicators := 0
+	// consonant is set while the current character is an Indic consonant followed by extends and linkers,
+	// linked once one of those is a linker, so that the next consonant joins the conjunct
+	consonant, linked := false, false
+
+	for i, r := range text {
+		class := classOf(r)
+		joinsPictograph := pictographic && unicode.Is(extendedPictographic, r)
+		joinsConsonant := linked && unicode.Is(indicConsonant, r)
+		if i == 0 || isBoundary(prev, class, joinsPictograph, joinsConsonant, regionalIndicators) {
+			count++
+			pictographic = false
+		}
+
+		switch {
+		case unicode.Is(extendedPictographic, r):
+			pictographic = true
+		case class == classExtend && prev != classZWJ, class == classZWJ:
+			// extends and the joiner keep the pictograph joinable
+		default:
+			pictographic = false
+		}
+		switch {
+		case unicode.Is(indicConsonant, r):
+			consonant, linked = true, false
+		case consonant && isIndicLinker(r):
+			linked = true
+		case consonant && (class == classExtend || class == classZWJ):
+			// extends and the joiner keep the consonant joinable, and a linker linked
+		default:
+			consonant, linked = false, false
+		}
+		if class == classRegionalIndicator {
+			regionalIndicators++
+		} else {
+			regionalIndicators = 0
+		}
+		prev = class
+	}
+	return count
+}
+
+// isBoundary reports whether a character boundary lies between a rune of class prev and one of class next.
+//
+// joinsPictograph is whether next is a pictograph that the sequence before it joins with,
+// joinsConsonant is whether next is an Indic consonant that the conjunct before it joins with,
+// regionalIndicators is the number of regional indicators in a row up to prev.
+func isBoundary(prev, next breakClass, joinsPictograph, joinsConsonant bool, regionalIndicators int) bool {
+	switch {
+	case prev == classCR && next == classLF:
+		return false
+	case prev == classCR, prev == classLF, prev == classControl:
+		return true
+	case next == classCR, next == classLF, next == classControl:
+		return true
+	case prev == classL && (next == classL || next == classV || next == classLV || next == classLVT):
+		return false
+	case (prev == classLV || prev == classV) && (next == classV || next == classT):
+		return false
+	case (prev == classLVT || prev == classT) && next == classT:
+		return false
+	case next == classExtend, next == classZWJ, next == classSpacingMark:
+		return false
+	case prev == classPrepend:
+		return false
+	case joinsConsonant:
+		return false
+	case prev == classZWJ && joinsPictograph:
+		return false
+	case prev == classRegionalIndicator && next == classRegionalIndicator:
+		return regionalIndicators%2 == 0
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package textlength
+
+import "testing"
+
+// TestGraphemes tests the Graphemes function with various test cases.
+//
+// It checks that characters built from several code points count as one.
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{
+			name: "Empty",
+			text: "",
+			want: 0,
+		},
+		{
+			name: "ASCII",
+			text: "chirp!",
+			want: 6,
+		},
+		{
+			name: "Multi-byte letters",
+			text: "árvíztűrő",
+			want: 9,
+		},
+		{
+			name: "Combining accents",
+			text: "a\u0301e\u0301",
+			want: 2,
+		},
+		{
+			name: "CJK",
+			text: "日本語",
+			want: 3,
+		},
+		{
+			name: "Emoji",
+			text: "😀😀😀",
+			want: 3,
+		},
+		{
+			name: "Skin tone modifier",
+			text: "👍🏽",
+			want: 1,
+		},
+		{
+			name: "ZWJ family",
+			text: "👩‍👩‍👧‍👦",
+			want: 1,
+		},
+		{
+			name: "Emoji presentation selector",
+			text: "❤️",
+			want: 1,
+		},
+		{
+			name: "Flags",
+			text: "🇭🇺🇯🇵",
+			want: 2,
+		},
+		{
+			name: "Odd regional indicator",
+			text: "🇭🇺🇯",
+			want: 2,
+		},
+		{
+			name: "Subdivision flag",
+			text: "🏴\U000e0067\U000e0062\U000e0065\U000e006e\U000e0067\U000e007f",
+			want: 1,
+		},
+		{
+			name: "Hangul jamo",
+			text: "\u1112\u1161\u11ab\u1100\u1173\u11af",
+			want: 2,
+		},
+		{
+			name: "Hangul syllables",
+			text: "한글",
+			want: 2,
+		},
+		{
+			name: "Devanagari spacing mark",
+			text: "हिंदी",
+			want: 2,
+		},
+		{
+			name: "Devanagari conjunct",
+			text: "नमस्ते",
+			want: 3,
+		},
+		{
+			name: "Devanagari conjunct of three consonants",
+			text: "स्त्री",
+			want: 1,
+		},
+		{
+			name: "Devanagari conjunct with nukta",
+			text: "ज़्या",
+			want: 1,
+		},
+		{
+			name: "Bengali conjunct",
+			text: "সন্ধ্যা",
+			want: 2,
+		},
+		{
+			name: "Bengali conjunct of two consonants",
+			text: "ক্ষ",
+			want: 1,
+		},
+		{
+			name: "Virama before a letter of another script",
+			text: "क्a",
+			want: 2,
+		},
+		{
+			name: "Tamil virama",
+			text: "க்ஷ",
+			want: 2,
+		},
+		{
+			name: "CRLF",
+			text: "a\r\nb",
+			want: 3,
+		},
+		{
+			name: "Joiner without pictographs",
+			text: "a‍b",
+			want: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Graphemes(tt.text)
+			if got != tt.want {
+				t.Errorf("Graphemes(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package textlength
+
+import (
+	"regexp"
+	"strings"
+)
+
+// URLWeight is how many characters a link counts as, however long or short it is.
+const URLWeight = 23
+
+// urlPattern matches the links counted at URLWeight: http and https URLs, and addresses starting with www.
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s]+`)
+
+// urlTrailingPunctuation is trimmed from the end of a link, "see https://example.com." links to example.com.
+const urlTrailingPunctuation = `.,:;!?'")]}`
+
+// Length measures a text in user-perceived characters, see Graphemes, with every link counting as URLWeight characters.
+//
+// Punctuation right after a link is not part of it and counts normally.
+// Returns the length of the text.
+func Length(text string) int {
+	length := 0
+	last := 0
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		end := loc[0] + len(strings.TrimRight(text[loc[0]:loc[1]], urlTrailingPunctuation))
+		if strings.HasSuffix(text[loc[0]:end], "://") {
+			// a scheme alone is not a link
+			continue
+		}
+		length += Graphemes(text[last:loc[0]]) + URLWeight
+		last = end
+	}
+	return length + Graphemes(text[last:])
+}
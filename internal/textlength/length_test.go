@@ -0,0 +1,67 @@
+package textlength
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestLength tests the Length function with various test cases.
+//
+// It checks that links count as URLWeight characters and everything else as user-perceived characters.
+func TestLength(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{
+			name: "No links",
+			text: "hello 👋🏽",
+			want: 7,
+		},
+		{
+			name: "Long link",
+			text: "https://example.com/" + strings.Repeat("a", 100),
+			want: URLWeight,
+		},
+		{
+			name: "Short link",
+			text: "see http://x.io",
+			want: 4 + URLWeight,
+		},
+		{
+			name: "www address",
+			text: "www.example.com",
+			want: URLWeight,
+		},
+		{
+			name: "Trailing punctuation",
+			text: "(https://example.com).",
+			want: 1 + URLWeight + 2,
+		},
+		{
+			name: "Two links",
+			text: "https://a.example https://b.example",
+			want: 2*URLWeight + 1,
+		},
+		{
+			name: "Scheme alone",
+			text: "https://",
+			want: 8,
+		},
+		{
+			name: "Inside a word",
+			text: "xhttps://example.com",
+			want: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Length(tt.text)
+			if got != tt.want {
+				t.Errorf("Length(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,378 @@
+// Package websocket implements the server side of the WebSocket protocol (RFC 6455) on top of net/http.
+// It supports what the API needs: text and binary messages, fragmentation, ping/pong and the closing handshake.
+// Extensions and subprotocols are not negotiated.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageType is the type of a data message.
+type MessageType int
+
+const (
+	// TextMessage is a message of UTF-8 text, like JSON.
+	TextMessage MessageType = 1
+	// BinaryMessage is a message of raw bytes.
+	BinaryMessage MessageType = 2
+)
+
+// The opcodes of the frames, see section 5.2 of RFC 6455.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// The close codes used by the server, see section 7.4.1 of RFC 6455.
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// acceptGUID is appended to the key of the client to compute Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxControlPayload is the longest payload of a control frame.
+const maxControlPayload = 125
+
+var (
+	// ErrBadHandshake is returned by Upgrade for a request that is not a valid WebSocket opening handshake.
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	// ErrClosed is returned by ReadMessage once the peer closed the connection,
+	// and by the write methods once the connection is closed.
+	ErrClosed = errors.New("websocket: connection closed")
+	// ErrMessageTooBig is returned by ReadMessage for a message longer than the read limit.
+	ErrMessageTooBig = errors.New("websocket: message too big")
+	// ErrProtocol is returned by ReadMessage for frames that break the protocol.
+	ErrProtocol = errors.New("websocket: protocol error")
+)
+
+// Conn is a WebSocket connection. ReadMessage must be called from a single goroutine,
+// the write methods may be called from any number of goroutines.
+type Conn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	readLimit int64
+
+	writeMu sync.Mutex
+	closed  bool
+
+	pongHandler func()
+}
+
+// AcceptKey computes the Sec-WebSocket-Accept header for the Sec-WebSocket-Key of a client.
+//
+// It takes the key sent by the client.
+// Returns the base64 SHA-1 of the key and the WebSocket GUID.
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Upgrade completes the opening handshake of a WebSocket connection and takes over the connection from net/http.
+//
+// It takes the http.ResponseWriter and the http.Request of the handshake.
+// Nothing is written for a request that is not a valid handshake, so the caller can respond with an error.
+// Returns the Conn, or an error wrapping ErrBadHandshake for an invalid handshake, or the error of taking over the connection.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		return nil, fmt.Errorf("%w: method is not GET", ErrBadHandshake)
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") {
+		return nil, fmt.Errorf("%w: Connection header doesn't contain upgrade", ErrBadHandshake)
+	}
+	if !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return nil, fmt.Errorf("%w: Upgrade header doesn't contain websocket", ErrBadHandshake)
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, fmt.Errorf("%w: unsupported version", ErrBadHandshake)
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	nonce, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(nonce) != 16 {
+		return nil, fmt.Errorf("%w: invalid Sec-WebSocket-Key", ErrBadHandshake)
+	}
+
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// the deadlines of the server don't apply to the connection anymore
+	netConn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n\r\n"
+	_, err = rw.WriteString(response)
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return newConn(netConn, rw.Reader), nil
+}
+
+// newConn wraps a connection that finished the opening handshake.
+func newConn(netConn net.Conn, reader *bufio.Reader) *Conn {
+	return &Conn{
+		conn:        netConn,
+		reader:      reader,
+		readLimit:   1 << 20,
+		pongHandler: func() {},
+	}
+}
+
+// headerContainsToken reports whether a comma-separated header contains a token, ignoring case.
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetReadLimit sets the longest message ReadMessage accepts, in bytes. The default is 1 MiB.
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetReadDeadline sets the deadline of the reads of ReadMessage, the zero time means no deadline.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetPongHandler sets the function called by ReadMessage when a pong arrives, usually to extend the read deadline.
+func (c *Conn) SetPongHandler(handler func()) {
+	c.pongHandler = handler
+}
+
+// ReadMessage reads the next data message, answering pings and handling pongs on the way.
+//
+// Fragmented messages are put back together. When the peer closes the connection,
+// its close frame is answered and ErrClosed is returned.
+// Returns the type and payload of the message, or an error. The connection can't be read from after an error.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	messageType := MessageType(0)
+	message := []byte{}
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			if errors.Is(err, ErrMessageTooBig) {
+				c.WriteClose(CloseMessageTooBig, "")
+			} else if errors.Is(err, ErrProtocol) {
+				c.WriteClose(CloseProtocolError, "")
+			}
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case opPing:
+			err = c.writeFrame(opPong, payload, time.Now().Add(time.Second))
+			if err != nil && !errors.Is(err, ErrClosed) {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			c.pongHandler()
+			continue
+		case opClose:
+			code := CloseNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			c.WriteClose(code, "")
+			return 0, nil, ErrClosed
+		case opText, opBinary:
+			if messageType != 0 {
+				c.WriteClose(CloseProtocolError, "")
+				return 0, nil, fmt.Errorf("%w: new message inside a fragmented one", ErrProtocol)
+			}
+			messageType = MessageType(opcode)
+		case opContinuation:
+			if messageType == 0 {
+				c.WriteClose(CloseProtocolError, "")
+				return 0, nil, fmt.Errorf("%w: continuation without a message", ErrProtocol)
+			}
+		default:
+			c.WriteClose(CloseProtocolError, "")
+			return 0, nil, fmt.Errorf("%w: unknown opcode %d", ErrProtocol, opcode)
+		}
+
+		if int64(len(message)+len(payload)) > c.readLimit {
+			c.WriteClose(CloseMessageTooBig, "")
+			return 0, nil, ErrMessageTooBig
+		}
+		message = append(message, payload...)
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(message) {
+				c.WriteClose(CloseInvalidPayload, "")
+				return 0, nil, fmt.Errorf("%w: text message is not UTF-8", ErrProtocol)
+			}
+			return messageType, message, nil
+		}
+	}
+}
+
+// readFrame reads a single frame and unmasks its payload.
+func (c *Conn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	header := make([]byte, 2)
+	_, err = io.ReadFull(c.reader, header)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, fmt.Errorf("%w: reserved bits set", ErrProtocol)
+	}
+	opcode = header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	if !masked {
+		return false, 0, nil, fmt.Errorf("%w: client frames must be masked", ErrProtocol)
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		_, err = io.ReadFull(c.reader, extended)
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		_, err = io.ReadFull(c.reader, extended)
+		length = binary.BigEndian.Uint64(extended)
+	}
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	isControl := opcode&0x8 != 0
+	if isControl && (!fin || length > maxControlPayload) {
+		return false, 0, nil, fmt.Errorf("%w: invalid control frame", ErrProtocol)
+	}
+	if length > uint64(c.readLimit) {
+		return false, 0, nil, ErrMessageTooBig
+	}
+
+	mask := make([]byte, 4)
+	_, err = io.ReadFull(c.reader, mask)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	_, err = io.ReadFull(c.reader, payload)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends a data message in a single frame.
+//
+// It takes the type of the message, its payload and the time the write must be done by.
+// Returns ErrClosed once the connection is closed, or the error of the write.
+func (c *Conn) WriteMessage(messageType MessageType, data []byte, deadline time.Time) error {
+	return c.writeFrame(byte(messageType), data, deadline)
+}
+
+// WritePing sends a ping, the peer answers it with a pong.
+//
+// It takes the time the write must be done by.
+// Returns ErrClosed once the connection is closed, or the error of the write.
+func (c *Conn) WritePing(deadline time.Time) error {
+	return c.writeFrame(opPing, nil, deadline)
+}
+
+// WriteClose starts or answers the closing handshake, nothing can be written after it.
+//
+// It takes the close code and a reason, which is cut to fit a control frame.
+// Returns ErrClosed if a close frame was already sent, or the error of the write.
+func (c *Conn) WriteClose(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > maxControlPayload {
+		payload = payload[:maxControlPayload]
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	err := c.writeFrameLocked(opClose, payload, time.Now().Add(time.Second))
+	c.closed = true
+	return err
+}
+
+// Close closes the underlying connection without a closing handshake, see WriteClose for a clean close.
+func (c *Conn) Close() error {
+	c.writeMu.Lock()
+	c.closed = true
+	c.writeMu.Unlock()
+	return c.conn.Close()
+}
+
+// writeFrame sends a single unmasked frame, as servers do.
+func (c *Conn) writeFrame(opcode byte, payload []byte, deadline time.Time) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	return c.writeFrameLocked(opcode, payload, deadline)
+}
+
+// writeFrameLocked is writeFrame for a caller holding c.writeMu.
+func (c *Conn) writeFrameLocked(opcode byte, payload []byte, deadline time.Time) error {
+	frame := make([]byte, 0, 10+len(payload))
+	frame = append(frame, 0x80|opcode)
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	frame = append(frame, payload...)
+
+	err := c.conn.SetWriteDeadline(deadline)
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.Write(frame)
+	return err
+}
@@ -0,0 +1,346 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// clientFrame builds a masked frame as a client sends it.
+func clientFrame(fin bool, opcode byte, payload []byte) []byte {
+	frame := []byte{opcode}
+	if fin {
+		frame[0] |= 0x80
+	}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, 0x80|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	mask := []byte{0x37, 0xfa, 0x21, 0x3d}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+// pipeConn returns a Conn reading the given bytes, and a function closing it and returning the bytes it wrote.
+func pipeConn(t *testing.T, input []byte) (*Conn, func() []byte) {
+	t.Helper()
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+	})
+
+	go func() {
+		client.Write(input)
+	}()
+	written := &bytes.Buffer{}
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(written, client)
+		close(copied)
+	}()
+
+	conn := newConn(server, bufio.NewReader(server))
+	return conn, func() []byte {
+		conn.Close()
+		<-copied
+		return written.Bytes()
+	}
+}
+
+// TestAcceptKey tests the Sec-WebSocket-Accept computation against the example of RFC 6455.
+func TestAcceptKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{
+			name: "RFC 6455 example",
+			key:  "dGhlIHNhbXBsZSBub25jZQ==",
+			want: "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AcceptKey(tt.key); got != tt.want {
+				t.Errorf("AcceptKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestUpgrade tests which opening handshakes are accepted.
+func TestUpgrade(t *testing.T) {
+	valid := func() http.Header {
+		return http.Header{
+			"Connection":            {"keep-alive, Upgrade"},
+			"Upgrade":               {"websocket"},
+			"Sec-Websocket-Version": {"13"},
+			"Sec-Websocket-Key":     {"dGhlIHNhbXBsZSBub25jZQ=="},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		header     func() http.Header
+		wantStatus int
+	}{
+		{
+			name:       "Valid handshake",
+			method:     http.MethodGet,
+			header:     valid,
+			wantStatus: http.StatusSwitchingProtocols,
+		},
+		{
+			name:       "Wrong method",
+			method:     http.MethodPost,
+			header:     valid,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Missing upgrade",
+			method: http.MethodGet,
+			header: func() http.Header {
+				header := valid()
+				header.Del("Upgrade")
+				return header
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Unsupported version",
+			method: http.MethodGet,
+			header: func() http.Header {
+				header := valid()
+				header.Set("Sec-WebSocket-Version", "8")
+				return header
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Invalid key",
+			method: http.MethodGet,
+			header: func() http.Header {
+				header := valid()
+				header.Set("Sec-WebSocket-Key", "short")
+				return header
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			if !errors.Is(err, ErrBadHandshake) {
+				t.Errorf("Upgrade() error = %v, want %v", err, ErrBadHandshake)
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		conn.WriteMessage(TextMessage, []byte("hello"), time.Now().Add(time.Second))
+		conn.Close()
+	}))
+	defer server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL, nil)
+			if err != nil {
+				t.Fatalf("NewRequest() error = %v", err)
+			}
+			req.Header = tt.header()
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %v, want %v", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusSwitchingProtocols {
+				return
+			}
+			if got, want := resp.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+				t.Errorf("Sec-WebSocket-Accept = %v, want %v", got, want)
+			}
+			frame, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if want := append([]byte{0x81, 5}, "hello"...); !bytes.Equal(frame, want) {
+				t.Errorf("frame = %v, want %v", frame, want)
+			}
+		})
+	}
+}
+
+// TestReadMessage tests the messages and errors read from the frames of a client.
+func TestReadMessage(t *testing.T) {
+	long := bytes.Repeat([]byte("a"), 300)
+
+	tests := []struct {
+		name      string
+		input     [][]byte
+		wantType  MessageType
+		wantData  []byte
+		wantErr   error
+		wantReply []byte
+	}{
+		{
+			name:     "Text message",
+			input:    [][]byte{clientFrame(true, opText, []byte("hello"))},
+			wantType: TextMessage,
+			wantData: []byte("hello"),
+		},
+		{
+			name:     "Binary message with a 16-bit length",
+			input:    [][]byte{clientFrame(true, opBinary, long)},
+			wantType: BinaryMessage,
+			wantData: long,
+		},
+		{
+			name: "Fragmented message with a ping in between",
+			input: [][]byte{
+				clientFrame(false, opText, []byte("hel")),
+				clientFrame(true, opPing, []byte("hi")),
+				clientFrame(true, opContinuation, []byte("lo")),
+			},
+			wantType:  TextMessage,
+			wantData:  []byte("hello"),
+			wantReply: []byte{0x8A, 2, 'h', 'i'},
+		},
+		{
+			name:      "Close",
+			input:     [][]byte{clientFrame(true, opClose, []byte{0x03, 0xE8})},
+			wantErr:   ErrClosed,
+			wantReply: []byte{0x88, 2, 0x03, 0xE8},
+		},
+		{
+			name:      "Unmasked frame",
+			input:     [][]byte{{0x81, 2, 'h', 'i'}},
+			wantErr:   ErrProtocol,
+			wantReply: []byte{0x88, 2, 0x03, 0xEA},
+		},
+		{
+			name:      "Continuation without a message",
+			input:     [][]byte{clientFrame(true, opContinuation, []byte("hi"))},
+			wantErr:   ErrProtocol,
+			wantReply: []byte{0x88, 2, 0x03, 0xEA},
+		},
+		{
+			name:      "Invalid UTF-8",
+			input:     [][]byte{clientFrame(true, opText, []byte{0xff, 0xfe})},
+			wantErr:   ErrProtocol,
+			wantReply: []byte{0x88, 2, 0x03, 0xEF},
+		},
+		{
+			name:      "Message over the read limit",
+			input:     [][]byte{clientFrame(true, opText, bytes.Repeat([]byte("a"), 2000))},
+			wantErr:   ErrMessageTooBig,
+			wantReply: []byte{0x88, 2, 0x03, 0xF1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, written := pipeConn(t, bytes.Join(tt.input, nil))
+			conn.SetReadLimit(1024)
+
+			gotType, gotData, err := conn.ReadMessage()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadMessage() error = %v, want %v", err, tt.wantErr)
+			}
+			if gotType != tt.wantType || !bytes.Equal(gotData, tt.wantData) {
+				t.Errorf("ReadMessage() = %v, %q, want %v, %q", gotType, gotData, tt.wantType, tt.wantData)
+			}
+
+			if got := written(); !bytes.Equal(got, tt.wantReply) {
+				t.Errorf("wrote %v, want %v", got, tt.wantReply)
+			}
+		})
+	}
+}
+
+// TestWriteMessage tests the frame headers written for each payload length encoding.
+func TestWriteMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		length     int
+		wantHeader []byte
+	}{
+		{
+			name:       "7-bit length",
+			length:     125,
+			wantHeader: []byte{0x81, 125},
+		},
+		{
+			name:       "16-bit length",
+			length:     126,
+			wantHeader: []byte{0x81, 126, 0, 126},
+		},
+		{
+			name:       "64-bit length",
+			length:     70000,
+			wantHeader: []byte{0x81, 127, 0, 0, 0, 0, 0, 1, 0x11, 0x70},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := net.Pipe()
+			defer client.Close()
+			conn := newConn(server, bufio.NewReader(server))
+
+			payload := bytes.Repeat([]byte("a"), tt.length)
+			go func() {
+				conn.WriteMessage(TextMessage, payload, time.Now().Add(time.Second))
+				conn.Close()
+			}()
+			frame, err := io.ReadAll(client)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+
+			want := append(tt.wantHeader, payload...)
+			if !bytes.Equal(frame, want) {
+				t.Errorf("frame header = %v, want %v", frame[:len(tt.wantHeader)], tt.wantHeader)
+			}
+		})
+	}
+}
+
+// TestWriteAfterClose tests that nothing is written after a close frame.
+func TestWriteAfterClose(t *testing.T) {
+	conn, written := pipeConn(t, nil)
+	defer written()
+
+	if err := conn.WriteClose(CloseNormal, ""); err != nil {
+		t.Fatalf("WriteClose() error = %v", err)
+	}
+	if err := conn.WriteMessage(TextMessage, []byte("hello"), time.Now().Add(time.Second)); !errors.Is(err, ErrClosed) {
+		t.Errorf("WriteMessage() error = %v, want %v", err, ErrClosed)
+	}
+	if err := conn.WriteClose(CloseNormal, ""); !errors.Is(err, ErrClosed) {
+		t.Errorf("WriteClose() error = %v, want %v", err, ErrClosed)
+	}
+}
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/ArrayOfLilly/chirp/internal/blobstore"
+	"github.com/ArrayOfLilly/chirp/internal/contentfilter"
 	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/mailer"
+	"github.com/ArrayOfLilly/chirp/internal/realtime"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
@@ -15,18 +25,39 @@ import (
 // a struct that will hold any stateful, in-memory data we'll need to keep track of
 // fileserverHits: an atomic integer to safely track the number of hits to the file server in a concurrent environment.
 // db: a pointer to a database.Queries object, which likely provides methods for interacting with the database.
+// dbConn: the underlying database connection pool, used to begin transactions for db.WithTx.
 // platform: a string representing the platform the API is running on.
 // jwtSecret: a string containing the secret key used for signing JSON Web Tokens (JWTs).
 // polkaKey: a string containing the Polka key (purpose not specified in this context).
+// blobs: the store for uploaded files, like the images attached to chirps.
+// adminKey: the API key of the admin endpoints that edit the content filter and moderate chirps, they are disabled without it.
+// fileFilterRules: the content filter rules of the word list file, the rules in the database are added to them.
+// contentFilter: the current content filter, swapped atomically when an admin edits its rules.
+// hub: the realtime hub, which pushes new chirps and notifications to the clients of the event stream and of the WebSocket API.
+// instanceID: identifies this instance of the server among those sharing the database, so it ignores the events it relayed itself.
+// mailer: sends the password reset emails, password reset is disabled without it.
+// passwordResetURL: the page of the client where users choose a new password, linked from the password reset emails with the token.
 type apiConfig struct {
 	// safely incrementable int type for case of concurrent use
 	fileserverHits 	atomic.Int32
 	db 				*database.Queries
+	dbConn			*sql.DB
 	platform       	string
 	jwtSecret		string
 	polkaKey		string
+	blobs			blobstore.Store
+	adminKey		string
+	fileFilterRules	[]contentfilter.Rule
+	contentFilter	atomic.Pointer[contentfilter.Filter]
+	hub				*realtime.Hub
+	instanceID		uuid.UUID
+	mailer			mailer.Mailer
+	passwordResetURL string
 }
 
+// shutdownTimeout is how long the server waits for requests in flight when it stops
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	filepathRoot := "."
 
@@ -65,12 +96,76 @@ func main() {
 		log.Fatal("POLKA_KEY environment variable must be set")
 	}
 
+	// uploaded media is kept on the local filesystem and served under /media/
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+	blobs, err := blobstore.NewLocalStore(mediaDir, "/media")
+	if err != nil {
+		log.Fatalf("Couldn't create media directory: %v", err)
+	}
+
+	// without an admin key nobody can use the admin endpoints
+	adminKey := os.Getenv("ADMIN_API_KEY")
+
+	// the word list file holds the base rules of the content filter, the defaults are used without one
+	fileFilterRules, err := readFilterRules(os.Getenv("CONTENT_FILTER_FILE"))
+	if err != nil {
+		log.Fatalf("Couldn't read content filter rules: %v", err)
+	}
+
+	// password reset emails go through an SMTP server, in development they are written to MAIL_FILE or the log instead,
+	// without either nobody can reset their password
+	var passwordResetMailer mailer.Mailer
+	mailFrom := os.Getenv("MAIL_FROM")
+	smtpHost := os.Getenv("SMTP_HOST")
+	switch {
+	case smtpHost != "":
+		if mailFrom == "" {
+			log.Fatal("MAIL_FROM must be set to send emails")
+		}
+		smtpPort := os.Getenv("SMTP_PORT")
+		if smtpPort == "" {
+			smtpPort = "587"
+		}
+		passwordResetMailer = mailer.NewSMTPMailer(smtpHost, smtpPort, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), mailFrom)
+	case platform == "dev":
+		if mailFrom == "" {
+			mailFrom = "chirpy@localhost"
+		}
+		mailOut := log.Writer()
+		mailFile := os.Getenv("MAIL_FILE")
+		if mailFile != "" {
+			file, err := os.OpenFile(mailFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+			if err != nil {
+				log.Fatalf("Couldn't open mail file: %v", err)
+			}
+			defer file.Close()
+			mailOut = file
+		}
+		passwordResetMailer = mailer.NewLogMailer(mailOut, mailFrom)
+	}
+
 	apiCfg := apiConfig{
 		fileserverHits: atomic.Int32{},
 		db:             dbQueries,
+		dbConn:			dbConn,
 		platform:       platform,
 		jwtSecret:		jwtSecret,
 		polkaKey:		polkaKey,
+		blobs:			blobs,
+		adminKey:		adminKey,
+		fileFilterRules: fileFilterRules,
+		hub:			realtime.NewHub(realtimeHistorySize, realtimeBufferSize),
+		instanceID:		uuid.New(),
+		mailer:			passwordResetMailer,
+		passwordResetURL: os.Getenv("PASSWORD_RESET_URL"),
+	}
+
+	err = apiCfg.reloadContentFilter(context.Background())
+	if err != nil {
+		log.Fatalf("Couldn't load content filter: %v", err)
 	}
 
 	// ServeMux is an HTTP request multiplexer. 
@@ -87,12 +182,19 @@ func main() {
 	// 	ServeHTTP(ResponseWriter, *Request)
 	// }
 
+	// the media directory may be inside the app directory, it is only served under /media/, which doesn't list it
+	appHandler, err := blobstore.ExcludeDir(http.FileServer(http.Dir(filepathRoot)), filepathRoot, mediaDir)
+	if err != nil {
+		log.Fatalf("Couldn't serve app directory: %v", err)
+	}
+
 	// FileServer returns a handler that serves HTTP requests with the contents of the file system rooted at root.
 	
 	// To serve a directory on disk (/) under an alternate URL
 	// path (/app), use StripPrefix to modify the request
 	// URL's path before the FileServer sees it:
-	mux.Handle("/app/", http.StripPrefix("/app", apiCfg.middlewareMetricsInc(http.FileServer(http.Dir(filepathRoot)))))
+	mux.Handle("/app/", http.StripPrefix("/app", apiCfg.middlewareMetricsInc(appHandler)))
+	mux.Handle("/media/", http.StripPrefix("/media", handlerMedia(mediaDir)))
 
 	// type HandlerFunc func(ResponseWriter, *Request)
 	// The HandlerFunc type is an adapter to allow the use of ordinary functions as HTTP handlers. 
@@ -102,22 +204,98 @@ func main() {
 	
 	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
 	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	mux.HandleFunc("GET /admin/content-filter/rules", apiCfg.handlerGetFilterRules)
+	mux.HandleFunc("POST /admin/content-filter/rules", apiCfg.handlerSaveFilterRule)
+	mux.HandleFunc("DELETE /admin/content-filter/rules/{ruleID}", apiCfg.handlerDeleteFilterRule)
+	mux.HandleFunc("GET /admin/moderation/chirps", apiCfg.handlerGetModerationQueue)
+	mux.HandleFunc("POST /admin/moderation/chirps/{chirpID}/approve", apiCfg.handlerApproveChirp)
+	mux.HandleFunc("POST /admin/moderation/chirps/{chirpID}/reject", apiCfg.handlerRejectChirp)
 
 	mux.HandleFunc("GET /api/healthz", handlerReady)
 
 	mux.HandleFunc("POST /api/users", apiCfg.handlerUserCreate)
 	mux.HandleFunc("PUT /api/users", apiCfg.handlerUserpdate)
+	mux.HandleFunc("GET /api/users/{handle}", apiCfg.handlerGetProfile)
+	mux.HandleFunc("GET /api/users/me/blocks", apiCfg.handlerGetBlockedUsers)
+	mux.HandleFunc("POST /api/users/me/blocks", apiCfg.handlerBlockUser)
+	mux.HandleFunc("DELETE /api/users/me/blocks/{userID}", apiCfg.handlerUnblockUser)
+	mux.HandleFunc("GET /api/users/me/mutes", apiCfg.handlerGetMutedUsers)
+	mux.HandleFunc("POST /api/users/me/mutes", apiCfg.handlerMuteUser)
+	mux.HandleFunc("DELETE /api/users/me/mutes/{userID}", apiCfg.handlerUnmuteUser)
+	mux.HandleFunc("GET /api/users/me/notification-preferences", apiCfg.handlerGetNotificationPreferences)
+	mux.HandleFunc("PUT /api/users/me/notification-preferences", apiCfg.handlerUpdateNotificationPreferences)
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.handlerFollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.handlerUnfollowUser)
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.handlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", apiCfg.handlerGetFollowing)
+	mux.HandleFunc("GET /api/users/{userID}/lists", apiCfg.handlerGetUserLists)
 
 
 	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
 	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
+	mux.HandleFunc("POST /api/password-reset/request", apiCfg.handlerPasswordResetRequest)
+	mux.HandleFunc("POST /api/password-reset/confirm", apiCfg.handlerPasswordResetConfirm)
 
 	mux.HandleFunc("POST /api/chirps", apiCfg.handlerChirpsCreate)
 	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetAllChirps)
+	mux.HandleFunc("GET /api/chirps/search", apiCfg.handlerSearchChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerGetChirpById)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", apiCfg.handlerUpdateChirpById)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apiCfg.handlerGetChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.handlerGetChirpThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/likes", apiCfg.handlerLikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/likes", apiCfg.handlerUnlikeChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/votes", apiCfg.handlerVotePoll)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", apiCfg.handlerBookmarkChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiCfg.handlerUnbookmarkChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiCfg.handlerRechirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.handlerUndoRechirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/quote", apiCfg.handlerQuoteChirp)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerDeleteChirpById)
 
+	mux.HandleFunc("GET /api/limits", apiCfg.handlerGetLimits)
+	mux.HandleFunc("GET /api/scheduled", apiCfg.handlerGetScheduledChirps)
+	mux.HandleFunc("PUT /api/scheduled/{chirpID}", apiCfg.handlerRescheduleChirp)
+	mux.HandleFunc("DELETE /api/scheduled/{chirpID}", apiCfg.handlerCancelScheduledChirp)
+
+	mux.HandleFunc("POST /api/drafts", apiCfg.handlerCreateDraft)
+	mux.HandleFunc("GET /api/drafts", apiCfg.handlerGetDrafts)
+	mux.HandleFunc("GET /api/drafts/{draftID}", apiCfg.handlerGetDraft)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", apiCfg.handlerUpdateDraft)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", apiCfg.handlerDeleteDraft)
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", apiCfg.handlerPublishDraft)
+
+	mux.HandleFunc("GET /api/bookmarks", apiCfg.handlerGetBookmarks)
+
+	mux.HandleFunc("POST /api/lists", apiCfg.handlerCreateList)
+	mux.HandleFunc("GET /api/lists/{listID}", apiCfg.handlerGetList)
+	mux.HandleFunc("PUT /api/lists/{listID}", apiCfg.handlerUpdateList)
+	mux.HandleFunc("DELETE /api/lists/{listID}", apiCfg.handlerDeleteList)
+	mux.HandleFunc("GET /api/lists/{listID}/members", apiCfg.handlerGetListMembers)
+	mux.HandleFunc("POST /api/lists/{listID}/members", apiCfg.handlerAddListMember)
+	mux.HandleFunc("DELETE /api/lists/{listID}/members/{userID}", apiCfg.handlerRemoveListMember)
+	mux.HandleFunc("GET /api/lists/{listID}/chirps", apiCfg.handlerGetListChirps)
+
+	mux.HandleFunc("POST /api/conversations", apiCfg.handlerCreateConversation)
+	mux.HandleFunc("GET /api/conversations", apiCfg.handlerGetConversations)
+	mux.HandleFunc("GET /api/conversations/{conversationID}", apiCfg.handlerGetConversation)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", apiCfg.handlerCreateMessage)
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", apiCfg.handlerGetMessages)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", apiCfg.handlerMarkConversationRead)
+
+	mux.HandleFunc("GET /api/notifications", apiCfg.handlerGetNotifications)
+	mux.HandleFunc("POST /api/notifications/read", apiCfg.handlerMarkAllNotificationsRead)
+	mux.HandleFunc("POST /api/notifications/{notificationID}/read", apiCfg.handlerMarkNotificationRead)
+
+	mux.HandleFunc("GET /api/timeline", apiCfg.handlerGetTimeline)
+	mux.HandleFunc("GET /api/mentions", apiCfg.handlerGetMentions)
+	mux.HandleFunc("GET /api/tags/trending", apiCfg.handlerGetTrendingTags)
+	mux.HandleFunc("GET /api/tags/{tag}/chirps", apiCfg.handlerGetTagChirps)
+
+	mux.HandleFunc("GET /api/stream", apiCfg.handlerStream)
+	mux.HandleFunc("GET /api/ws", apiCfg.handlerWebSocket)
+
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerUserUpgrade)
 	
 	// A Server defines parameters for running an HTTP server. 
@@ -127,11 +305,38 @@ func main() {
 		Handler: mux,
 	}
 
+	// Shutdown doesn't wait for event streams to end on their own, closing the hub ends them
+	srv.RegisterOnShutdown(apiCfg.hub.Close)
+
+	// the server stops on SIGINT or SIGTERM, the background work stops with it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// scheduled chirps are published in the background, every instance may run a publisher
+	go apiCfg.runScheduledPublisher(ctx)
+
+	// the events of the other instances reach the clients of this one through Postgres LISTEN/NOTIFY
+	go apiCfg.runEventListener(ctx, dbURL)
+
 	// ListenAndServe listens on the TCP network address srv.Addr and 
 	// then calls Serve to handle requests on incoming connections. 
 	// opens a TCP socket
-	log.Printf("Serving on port: %s\n", port)
-	log.Fatal(srv.ListenAndServe())
+	go func() {
+		log.Printf("Serving on port: %s\n", port)
+		err := srv.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err = srv.Shutdown(shutdownCtx)
+	if err != nil {
+		log.Printf("Couldn't shut down cleanly: %v", err)
+	}
 }
 
 
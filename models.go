@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/entities"
 	"github.com/google/uuid"
 )
 
@@ -13,6 +14,10 @@ type User struct {
     UpdatedAt 		time.Time	`json:"updated_at"`
     Email      		string		`json:"email"`
 	IsChirpyRed		bool		`json:"is_chirpy_red"`
+	Handle			string		`json:"handle"`
+	DisplayName		string		`json:"display_name"`
+	Bio				string		`json:"bio"`
+	AvatarURL		string		`json:"avatar_url"`
 }
 
 // databaseUserToUser converts a database.User object to a User object.
@@ -26,27 +31,390 @@ func databaseUserToUser(user database.User) User {
         UpdatedAt:	 user.UpdatedAt,
         Email:		 user.Email,
 		IsChirpyRed: user.IsChirpyRed,
+		Handle:		 user.Handle,
+		DisplayName: user.DisplayName,
+		Bio:		 user.Bio,
+		AvatarURL:	 user.AvatarUrl,
     }
 }
 
+// UserSummary is the public view of a user, it never carries private fields like the email.
+type UserSummary struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Handle      string    `json:"handle"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+// databaseUserToUserSummary converts a database.User object to a UserSummary object.
+//
+// It takes a database.User object as a parameter.
+// Returns a UserSummary object.
+func databaseUserToUserSummary(user database.User) UserSummary {
+	return UserSummary{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		Handle:      user.Handle,
+		DisplayName: user.DisplayName,
+		AvatarURL:   user.AvatarUrl,
+		IsChirpyRed: user.IsChirpyRed,
+	}
+}
+
+// Profile is the public profile of a user, shown on its handle's page.
+type Profile struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Handle         string    `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	AvatarURL      string    `json:"avatar_url"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	ChirpCount     int64     `json:"chirp_count"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+}
+
+// databaseProfileToProfile converts a database.GetUserProfileByHandleRow object to a Profile object.
+//
+// It takes a database.GetUserProfileByHandleRow object as a parameter.
+// Returns a Profile object.
+func databaseProfileToProfile(profile database.GetUserProfileByHandleRow) Profile {
+	return Profile{
+		ID:             profile.ID,
+		CreatedAt:      profile.CreatedAt,
+		Handle:         profile.Handle,
+		DisplayName:    profile.DisplayName,
+		Bio:            profile.Bio,
+		AvatarURL:      profile.AvatarUrl,
+		IsChirpyRed:    profile.IsChirpyRed,
+		ChirpCount:     profile.ChirpCount,
+		FollowerCount:  profile.FollowerCount,
+		FollowingCount: profile.FollowingCount,
+	}
+}
+
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string	`json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID             uuid.UUID    `json:"id"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	Body           string       `json:"body"`
+	UserID         uuid.UUID    `json:"user_id"`
+	AuthorHandle   string       `json:"author_handle"`
+	InReplyTo      *uuid.UUID   `json:"in_reply_to"`
+	Mentions       []Mention    `json:"mentions"`
+	Hashtags       []Hashtag    `json:"hashtags"`
+	Attachments    []Attachment `json:"attachments"`
+	Poll           *Poll        `json:"poll"`
+	ReplyCount     int64        `json:"reply_count"`
+	LikeCount      int64        `json:"like_count"`
+	LikedByMe      bool         `json:"liked_by_me"`
+	BookmarkedByMe bool         `json:"bookmarked_by_me"`
+	RechirpCount   int64        `json:"rechirp_count"`
+	QuoteCount     int64        `json:"quote_count"`
+	// Status is "published", "pending_review" or "rejected" for chirps held back by the content filter,
+	// or "scheduled" for chirps to be published at PublishAt
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// RepostKind is "rechirp" or "quote" for reposts of another chirp, Original is then the reposted chirp
+	RepostKind string         `json:"repost_kind,omitempty"`
+	Original   *OriginalChirp `json:"original,omitempty"`
+
+	// repostOfID is the ID of the original, kept for hydrateChirps
+	repostOfID uuid.UUID
+}
+
+// Poll is the poll of a chirp. The votes are counted live, but they are nil for a viewer who hasn't voted
+// until the poll closes, VotedOptionID is the option the viewer voted for.
+type Poll struct {
+	ClosesAt      time.Time    `json:"closes_at"`
+	Closed        bool         `json:"closed"`
+	Options       []PollOption `json:"options"`
+	TotalVotes    *int64       `json:"total_votes"`
+	VotedOptionID *uuid.UUID   `json:"voted_option_id"`
+}
+
+// PollOption is an option of a poll, in the order the author wrote them.
+type PollOption struct {
+	ID    uuid.UUID `json:"id"`
+	Label string    `json:"label"`
+	Votes *int64    `json:"votes"`
+}
+
+// Mention is an @handle in the body of a chirp that refers to an existing user.
+// Start and End are offsets in characters (Unicode code points) into the body, End is exclusive.
+type Mention struct {
+	UserID uuid.UUID `json:"user_id"`
+	Handle string    `json:"handle"`
+	Start  int       `json:"start"`
+	End    int       `json:"end"`
+}
+
+// Hashtag is a #tag in the body of a chirp.
+// Tag is normalized, Start and End are offsets like those of Mention.
+type Hashtag struct {
+	Tag   string `json:"tag"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Attachment is an image attached to a chirp, with a thumbnail for timelines.
+type Attachment struct {
+	ID              uuid.UUID `json:"id"`
+	URL             string    `json:"url"`
+	ContentType     string    `json:"content_type"`
+	Width           int       `json:"width"`
+	Height          int       `json:"height"`
+	ThumbnailURL    string    `json:"thumbnail_url"`
+	ThumbnailWidth  int       `json:"thumbnail_width"`
+	ThumbnailHeight int       `json:"thumbnail_height"`
+	AltText         string    `json:"alt_text"`
+}
+
+// OriginalChirp is the chirp a rechirp or quote chirp reposts.
+// When the original has been deleted it is a tombstone, with only Tombstone set.
+type OriginalChirp struct {
+	*Chirp
+	Tombstone bool `json:"tombstone,omitempty"`
 }
 
 // databaseChirpToChirp converts a database.Chirp object to a Chirp object.
 //
 // It takes a database.Chirp object as a parameter.
 // Returns a Chirp object.
+// The author handle, mentions, attachments, counters and the original of a repost are left empty, hydrateChirps fills them in.
 func databaseChirpToChirp(chirp database.Chirp) Chirp {
+	var inReplyTo *uuid.UUID
+	if chirp.ReplyToID.Valid {
+		inReplyTo = &chirp.ReplyToID.UUID
+	}
+
+	// the publish time only matters until the chirp is published
+	var publishAt *time.Time
+	if chirp.PublishAt.Valid && chirp.Status != chirpStatusPublished {
+		publishAt = &chirp.PublishAt.Time
+	}
+
+	// the original of a repost is loaded by hydrateChirps, unless it is already gone
+	var original *OriginalChirp
+	if chirp.RepostKind.Valid && !chirp.RepostOfID.Valid {
+		original = &OriginalChirp{Tombstone: true}
+	}
+
+	// hashtags come straight from the body, the tag tables only index them
+	hashtags := []Hashtag{}
+	for _, hashtag := range entities.ParseHashtags(chirp.Body) {
+		hashtags = append(hashtags, Hashtag{
+			Tag:   hashtag.Tag,
+			Start: hashtag.Start,
+			End:   hashtag.End,
+		})
+	}
+
 	return Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+		ID:         chirp.ID,
+		CreatedAt:  chirp.CreatedAt,
+		UpdatedAt:  chirp.UpdatedAt,
+		Body:       chirp.Body,
+		UserID:     chirp.UserID,
+		Status:     chirp.Status,
+		PublishAt:  publishAt,
+		InReplyTo:  inReplyTo,
+		Hashtags:   hashtags,
+		RepostKind: chirp.RepostKind.String,
+		Original:   original,
+		repostOfID: chirp.RepostOfID.UUID,
 	}
-}
\ No newline at end of file
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// databaseChirpRevisionToChirpRevision converts a database.ChirpRevision object to a ChirpRevision object.
+//
+// It takes a database.ChirpRevision object as a parameter.
+// Returns a ChirpRevision object.
+func databaseChirpRevisionToChirpRevision(revision database.ChirpRevision) ChirpRevision {
+	return ChirpRevision{
+		ID:         revision.ID,
+		ChirpID:    revision.ChirpID,
+		Body:       revision.Body,
+		CreatedAt:  revision.CreatedAt,
+		ReplacedAt: revision.ReplacedAt,
+	}
+}
+
+// FilterRule is a content filter rule stored in the database.
+type FilterRule struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Word      string    `json:"word"`
+	Action    string    `json:"action"`
+}
+
+// databaseFilterRuleToFilterRule converts a database.ContentFilterRule object to a FilterRule object.
+//
+// It takes a database.ContentFilterRule object as a parameter.
+// Returns a FilterRule object.
+func databaseFilterRuleToFilterRule(rule database.ContentFilterRule) FilterRule {
+	return FilterRule{
+		ID:        rule.ID,
+		CreatedAt: rule.CreatedAt,
+		UpdatedAt: rule.UpdatedAt,
+		Word:      rule.Word,
+		Action:    rule.Action,
+	}
+}
+
+// Draft is a chirp in progress, saved for its author only.
+type Draft struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	InReplyTo *uuid.UUID `json:"in_reply_to"`
+}
+
+// databaseDraftToDraft converts a database.Draft object to a Draft object.
+//
+// It takes a database.Draft object as a parameter.
+// Returns a Draft object.
+func databaseDraftToDraft(draft database.Draft) Draft {
+	var inReplyTo *uuid.UUID
+	if draft.ReplyToID.Valid {
+		inReplyTo = &draft.ReplyToID.UUID
+	}
+
+	return Draft{
+		ID:        draft.ID,
+		CreatedAt: draft.CreatedAt,
+		UpdatedAt: draft.UpdatedAt,
+		Body:      draft.Body,
+		InReplyTo: inReplyTo,
+	}
+}
+
+// List is a named list of accounts curated by a user, its timeline holds the chirps of its members.
+// A private list is visible only to its owner.
+type List struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Private     bool      `json:"private"`
+}
+
+// databaseListToList converts a database.List object to a List object.
+//
+// It takes a database.List object as a parameter.
+// Returns a List object.
+func databaseListToList(list database.List) List {
+	return List{
+		ID:          list.ID,
+		CreatedAt:   list.CreatedAt,
+		UpdatedAt:   list.UpdatedAt,
+		UserID:      list.UserID,
+		Name:        list.Name,
+		Description: list.Description,
+		Private:     list.IsPrivate,
+	}
+}
+
+// Conversation is a direct message conversation as seen by one of its participants.
+type Conversation struct {
+	ID           uuid.UUID                 `json:"id"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+	IsGroup      bool                      `json:"is_group"`
+	Participants []ConversationParticipant `json:"participants"`
+	UnreadCount  int64                     `json:"unread_count"`
+}
+
+// ConversationParticipant is a participant of a conversation with their read receipt,
+// messages sent up to last_read_at have been read by them.
+type ConversationParticipant struct {
+	UserSummary
+	LastReadAt *time.Time `json:"last_read_at"`
+}
+
+// databaseConversationToConversation converts a database.Conversation object to a Conversation object.
+//
+// It takes a database.Conversation object as a parameter.
+// Returns a Conversation object without participants, see apiConfig.hydrateConversations.
+func databaseConversationToConversation(conversation database.Conversation) Conversation {
+	return Conversation{
+		ID:           conversation.ID,
+		CreatedAt:    conversation.CreatedAt,
+		UpdatedAt:    conversation.UpdatedAt,
+		IsGroup:      conversation.IsGroup,
+		Participants: []ConversationParticipant{},
+	}
+}
+
+// Message is a message sent in a conversation.
+type Message struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+}
+
+// databaseMessageToMessage converts a database.Message object to a Message object.
+//
+// It takes a database.Message object as a parameter.
+// Returns a Message object.
+func databaseMessageToMessage(message database.Message) Message {
+	return Message{
+		ID:             message.ID,
+		CreatedAt:      message.CreatedAt,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		Body:           message.Body,
+	}
+}
+
+// Notification is a group of notifications of the same type about the same chirp on the same day,
+// like "alice and 4 others liked your chirp". The ID is the ID of the group, the chirp is the chirp the group is about,
+// the chirp replied to for a group of replies.
+type Notification struct {
+	ID          uuid.UUID     `json:"id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	Type        string        `json:"type"`
+	ChirpID     *uuid.UUID    `json:"chirp_id,omitempty"`
+	Text        string        `json:"text"`
+	Actors      []UserSummary `json:"actors"`
+	ActorCount  int64         `json:"actor_count"`
+	UnreadCount int64         `json:"unread_count"`
+}
+
+// NotificationPreferences holds which types of notifications a user receives.
+type NotificationPreferences struct {
+	Likes    bool `json:"likes"`
+	Replies  bool `json:"replies"`
+	Follows  bool `json:"follows"`
+	Mentions bool `json:"mentions"`
+}
+
+// databaseUserToNotificationPreferences reads the notification preferences of a user.
+//
+// It takes a database.User object as a parameter.
+// Returns a NotificationPreferences object.
+func databaseUserToNotificationPreferences(user database.User) NotificationPreferences {
+	return NotificationPreferences{
+		Likes:    user.NotifyLikes,
+		Replies:  user.NotifyReplies,
+		Follows:  user.NotifyFollows,
+		Mentions: user.NotifyMentions,
+	}
+}
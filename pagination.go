@@ -0,0 +1,128 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageCursor is the position after which the next page starts.
+// Clients only ever see it as an opaque string.
+// Rank is only set by search results, which are ordered by relevance first.
+type pageCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+	Rank      *float32  `json:"r,omitempty"`
+}
+
+// encodeCursor turns a pageCursor into the opaque string handed out as next_cursor.
+//
+// It takes the created_at and id of the last item on the current page.
+// Returns the base64 encoded cursor.
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	return pageCursor{
+		CreatedAt: createdAt,
+		ID:        id,
+	}.encode()
+}
+
+// encodeRankedCursor is encodeCursor for pages ordered by search rank.
+//
+// It takes the rank, created_at and id of the last item on the current page.
+// Returns the base64 encoded cursor.
+func encodeRankedCursor(rank float32, createdAt time.Time, id uuid.UUID) string {
+	return pageCursor{
+		CreatedAt: createdAt,
+		ID:        id,
+		Rank:      &rank,
+	}.encode()
+}
+
+func (c pageCursor) encode() string {
+	dat, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(dat)
+}
+
+// decodeCursor parses a cursor string previously produced by encodeCursor.
+//
+// It takes the opaque cursor string as a parameter.
+// Returns the decoded pageCursor and an error if the cursor is malformed.
+func decodeCursor(s string) (pageCursor, error) {
+	cursor := pageCursor{}
+	dat, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	err = json.Unmarshal(dat, &cursor)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	if cursor.ID == uuid.Nil || cursor.CreatedAt.IsZero() {
+		return pageCursor{}, errors.New("incomplete cursor")
+	}
+	return cursor, nil
+}
+
+// pageRequest holds the pagination parameters of a list request.
+type pageRequest struct {
+	Limit  int32
+	Cursor *pageCursor
+}
+
+// parsePageRequest reads the optional limit and cursor query parameters.
+//
+// It takes the http.Request as a parameter.
+// Returns the pageRequest and an error if either parameter is invalid.
+func parsePageRequest(r *http.Request) (pageRequest, error) {
+	page := pageRequest{
+		Limit: defaultPageSize,
+	}
+
+	limitString := r.URL.Query().Get("limit")
+	if limitString != "" {
+		limit, err := strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return pageRequest{}, errors.New("Invalid limit")
+		}
+		page.Limit = int32(limit)
+	}
+
+	cursorString := r.URL.Query().Get("cursor")
+	if cursorString != "" {
+		cursor, err := decodeCursor(cursorString)
+		if err != nil {
+			return pageRequest{}, errors.New("Invalid cursor")
+		}
+		page.Cursor = &cursor
+	}
+
+	return page, nil
+}
+
+// cursorParams converts the cursor into the nullable query parameters used by the keyset queries.
+//
+// Returns NULLs when the request has no cursor, so the queries start from the first row.
+func (p pageRequest) cursorParams() (sql.NullTime, uuid.NullUUID) {
+	if p.Cursor == nil {
+		return sql.NullTime{}, uuid.NullUUID{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true},
+		uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+// fetchLimit is the number of rows to ask the database for.
+// One extra row tells whether there is a next page.
+func (p pageRequest) fetchLimit() int32 {
+	return p.Limit + 1
+}
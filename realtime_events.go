@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/ArrayOfLilly/chirp/internal/database"
+	"github.com/ArrayOfLilly/chirp/internal/realtime"
+	"github.com/google/uuid"
+)
+
+// The types of the events pushed to the clients connected to GET /api/stream and GET /api/ws.
+const (
+	eventTypeChirp        = "chirp"
+	eventTypeChirpDeleted = "chirp_deleted"
+	eventTypeNotification = "notification"
+	eventTypeUserUpgraded = "user_upgraded"
+)
+
+// relayTypeContentFilterChanged is the type of the event telling the other instances to reload their content filter
+// after an admin edited its rules. It is only relayed, the clients aren't told.
+const relayTypeContentFilterChanged = "content_filter_changed"
+
+// eventTypeHiddenUsersChanged is the type of the event telling the connections of a user to reload the users hidden from them
+// after the user blocked, unblocked, muted or unmuted someone, or was blocked or unblocked. The clients aren't told.
+const eventTypeHiddenUsersChanged = "hidden_users_changed"
+
+// The topics of the events, which WebSocket clients subscribe to.
+// The topics of an author and of a thread are followed by a colon and the ID of the user or of the chirp.
+const (
+	topicFeed          = "feed"
+	topicAuthor        = "author"
+	topicThread        = "thread"
+	topicNotifications = "notifications"
+	topicAccount       = "account"
+)
+
+const (
+	// realtimeHistorySize is how many events are kept for clients resuming with Last-Event-ID
+	realtimeHistorySize = 1024
+	// realtimeBufferSize is how many events a client may fall behind before its stream is ended
+	realtimeBufferSize = 64
+	// realtimeChannel is the Postgres NOTIFY channel relaying the events between the instances of the server
+	realtimeChannel = "chirp_events"
+)
+
+// publishEvent encodes the payload of an event, publishes it to the realtime hub and relays it to the other instances.
+//
+// It takes a context, the type of the event, the user it is for (uuid.Nil for everyone),
+// the users whose actions or chirps it carries, the topics of the event, the ID of what the event is about and the payload.
+// The event is published to the hub of this instance first, so its clients receive it even if the relay fails.
+// Only the ID is relayed, the other instances rebuild the payload from it, see relayedEventPayload.
+// The action has already happened, so a payload that can't be encoded or relayed is logged rather than returned.
+func (cfg *apiConfig) publishEvent(ctx context.Context, eventType string, userID uuid.UUID, actorIDs []uuid.UUID, topics []string, subjectID uuid.UUID, payload any) {
+	dat, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Couldn't encode %s event: %v", eventType, err)
+		return
+	}
+	cfg.hub.Publish(realtime.Event{
+		Type:     eventType,
+		UserID:   userID,
+		ActorIDs: actorIDs,
+		Topics:   topics,
+		Data:     dat,
+	})
+
+	cfg.relayEvent(ctx, realtime.Relay{
+		Type:      eventType,
+		UserID:    userID,
+		ActorIDs:  actorIDs,
+		Topics:    topics,
+		SubjectID: subjectID,
+	})
+}
+
+// relayEvent relays an event to the other instances of the server.
+//
+// It takes a context and the event, its origin is set to this instance.
+// A relay that fails is logged rather than returned, the other instances miss the event.
+func (cfg *apiConfig) relayEvent(ctx context.Context, relay realtime.Relay) {
+	relay.Origin = cfg.instanceID
+	relayed, err := realtime.EncodeRelay(relay)
+	if err == nil {
+		err = cfg.db.NotifyEvent(ctx, database.NotifyEventParams{
+			Channel: realtimeChannel,
+			Payload: string(relayed),
+		})
+	}
+	if err != nil {
+		log.Printf("Couldn't relay %s event to the other instances: %v", relay.Type, err)
+	}
+}
+
+// publishChirps tells every connected client about new chirps.
+//
+// It takes a context and the chirps as stored, those that are not published are left out.
+// The chirps are sent as seen by an anonymous reader, without the likes and votes of any user.
+func (cfg *apiConfig) publishChirps(ctx context.Context, dbChirps []database.Chirp) {
+	chirps := []Chirp{}
+	for _, dbChirp := range dbChirps {
+		if dbChirp.Status == chirpStatusPublished {
+			chirps = append(chirps, databaseChirpToChirp(dbChirp))
+		}
+	}
+	if len(chirps) == 0 {
+		return
+	}
+
+	err := cfg.hydrateChirps(ctx, uuid.Nil, chirps)
+	if err != nil {
+		log.Printf("Couldn't publish chirps: %v", err)
+		return
+	}
+	for _, chirp := range chirps {
+		topics, err := cfg.chirpTopics(ctx, chirp.UserID, chirp.ID)
+		if err != nil {
+			log.Printf("Couldn't publish chirp: %v", err)
+			continue
+		}
+		// the reposted chirp is embedded whole, so its author decides who may see the event as much as the reposter
+		actorIDs := []uuid.UUID{chirp.UserID}
+		if chirp.Original != nil && chirp.Original.Chirp != nil {
+			actorIDs = append(actorIDs, chirp.Original.UserID)
+		}
+		cfg.publishEvent(ctx, eventTypeChirp, uuid.Nil, actorIDs, topics, chirp.ID, chirp)
+	}
+}
+
+// chirpDeletedEvent is the payload of an event about a deleted chirp.
+type chirpDeletedEvent struct {
+	ID uuid.UUID `json:"id"`
+}
+
+// publishChirpDeleted tells every connected client that a published chirp is gone.
+//
+// It takes a context and the chirp as it was stored, nobody is told about chirps they couldn't see.
+func (cfg *apiConfig) publishChirpDeleted(ctx context.Context, chirp database.Chirp) {
+	if chirp.Status != chirpStatusPublished {
+		return
+	}
+	// the chirp itself is gone, its thread is found from the chirp it replied to
+	topics := []string{topicFeed, topicAuthor + ":" + chirp.UserID.String(), topicThread + ":" + chirp.ID.String()}
+	if chirp.ReplyToID.Valid {
+		parentTopics, err := cfg.chirpTopics(ctx, uuid.Nil, chirp.ReplyToID.UUID)
+		if err != nil {
+			log.Printf("Couldn't publish deleted chirp: %v", err)
+			return
+		}
+		topics = append(topics, parentTopics...)
+	}
+	cfg.publishEvent(ctx, eventTypeChirpDeleted, uuid.Nil, []uuid.UUID{chirp.UserID}, topics, chirp.ID, chirpDeletedEvent{
+		ID: chirp.ID,
+	})
+}
+
+// chirpTopics finds the topics of an event about a chirp.
+//
+// It takes a context, the ID of the author (uuid.Nil to leave out the feed and the author) and the ID of the chirp.
+// A chirp belongs to its own thread and to the thread of every chirp above it.
+// Returns the topics, or an error if the ancestors of the chirp can't be retrieved.
+func (cfg *apiConfig) chirpTopics(ctx context.Context, authorID, chirpID uuid.UUID) ([]string, error) {
+	ancestors, err := cfg.db.GetThreadAncestors(ctx, database.GetThreadAncestorsParams{
+		ChirpID:  chirpID,
+		MaxDepth: maxAncestorDepth,
+		ViewerID: uuid.Nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	topics := make([]string, 0, len(ancestors)+3)
+	if authorID != uuid.Nil {
+		topics = append(topics, topicFeed, topicAuthor+":"+authorID.String())
+	}
+	topics = append(topics, topicThread+":"+chirpID.String())
+	for _, ancestor := range ancestors {
+		topics = append(topics, topicThread+":"+ancestor.ID.String())
+	}
+	return topics, nil
+}
+
+// publishNotifications tells the notified users about their new notifications.
+//
+// It takes a context and the notifications as stored. Each one is sent as a group of its own with the ID of its group,
+// merging it into a group already listed by GET /api/notifications is up to the client.
+func (cfg *apiConfig) publishNotifications(ctx context.Context, notifications []database.Notification) {
+	if len(notifications) == 0 {
+		return
+	}
+
+	actorIDs := make([]uuid.UUID, 0, len(notifications))
+	groupIDs := make([]uuid.UUID, 0, len(notifications))
+	for _, notification := range notifications {
+		actorIDs = append(actorIDs, notification.ActorID)
+		groupIDs = append(groupIDs, notification.GroupID)
+	}
+	actors, err := cfg.db.GetUsersByIDs(ctx, actorIDs)
+	if err != nil {
+		log.Printf("Couldn't publish notifications: %v", err)
+		return
+	}
+	actorByID := map[uuid.UUID]UserSummary{}
+	for _, actor := range actors {
+		actorByID[actor.ID] = databaseUserToUserSummary(actor)
+	}
+	groups, err := cfg.db.GetNotificationGroupsByIDs(ctx, groupIDs)
+	if err != nil {
+		log.Printf("Couldn't publish notifications: %v", err)
+		return
+	}
+	groupByID := map[uuid.UUID]database.NotificationGroup{}
+	for _, group := range groups {
+		groupByID[group.ID] = group
+	}
+
+	for _, notification := range notifications {
+		actor, ok := actorByID[notification.ActorID]
+		if !ok {
+			continue
+		}
+		group, ok := groupByID[notification.GroupID]
+		if !ok {
+			continue
+		}
+		cfg.publishEvent(ctx, eventTypeNotification, notification.UserID, []uuid.UUID{notification.ActorID}, []string{topicNotifications},
+			notification.ID, notificationEvent(notification, group, actor))
+	}
+}
+
+// notificationEvent builds the payload of an event about a new notification, a group of its own.
+//
+// It takes the notification as stored, its group and its actor.
+// Returns the payload.
+func notificationEvent(notification database.Notification, group database.NotificationGroup, actor UserSummary) Notification {
+	payload := Notification{
+		ID:          group.ID,
+		CreatedAt:   notification.CreatedAt,
+		Type:        notification.Type,
+		Text:        notificationText(notification.Type, []UserSummary{actor}, 1),
+		Actors:      []UserSummary{actor},
+		ActorCount:  1,
+		UnreadCount: 1,
+	}
+	if group.SubjectChirpID.Valid {
+		payload.ChirpID = &group.SubjectChirpID.UUID
+	}
+	return payload
+}
+
+// publishUserUpgraded tells a user that their account was upgraded to Chirpy Red.
+//
+// It takes a context and the user as stored after the upgrade.
+func (cfg *apiConfig) publishUserUpgraded(ctx context.Context, user database.User) {
+	cfg.publishEvent(ctx, eventTypeUserUpgraded, user.ID, nil, []string{topicAccount}, user.ID, databaseUserToUser(user))
+}
+
+// publishHiddenUsersChanged tells the connections of users that the users hidden from them changed.
+//
+// It takes a context and the IDs of the users: both users of a block, only the muting user of a mute.
+func (cfg *apiConfig) publishHiddenUsersChanged(ctx context.Context, userIDs ...uuid.UUID) {
+	for _, userID := range userIDs {
+		cfg.publishEvent(ctx, eventTypeHiddenUsersChanged, userID, nil, nil, userID, struct{}{})
+	}
+}
+
+// hiddenActors leaves out the events of users hidden from a connected user: those the user blocked, was blocked by or muted.
+// The hidden users are loaded once for the connection, and again on each eventTypeHiddenUsersChanged event for the user.
+type hiddenActors struct {
+	db     *database.Queries
+	userID uuid.UUID
+	ids    map[uuid.UUID]bool
+}
+
+// newHiddenActors creates the hiddenActors of a connected user and loads the users hidden from them.
+//
+// It takes a context and the ID of the user, uuid.Nil for an anonymous reader, from whom nobody is hidden.
+// Returns the hiddenActors, or an error if the hidden users can't be retrieved.
+func (cfg *apiConfig) newHiddenActors(ctx context.Context, userID uuid.UUID) (*hiddenActors, error) {
+	h := &hiddenActors{
+		db:     cfg.db,
+		userID: userID,
+		ids:    map[uuid.UUID]bool{},
+	}
+	err := h.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// load retrieves the users hidden from the connected user, replacing those loaded before.
+//
+// It takes a context.
+// Returns an error if the query fails, the users loaded before are kept then.
+func (h *hiddenActors) load(ctx context.Context) error {
+	if h.userID == uuid.Nil {
+		return nil
+	}
+	hiddenIDs, err := h.db.GetHiddenUserIDs(ctx, h.userID)
+	if err != nil {
+		return err
+	}
+	ids := make(map[uuid.UUID]bool, len(hiddenIDs))
+	for _, id := range hiddenIDs {
+		ids[id] = true
+	}
+	h.ids = ids
+	return nil
+}
+
+// hides reports whether an event carries the action or chirp of a user hidden from the connected user.
+func (h *hiddenActors) hides(event realtime.Event) bool {
+	for _, actorID := range event.ActorIDs {
+		if h.ids[actorID] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ArrayOfLilly/chirp/internal/realtime"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// relayMinReconnectInterval is how long the listener waits before reconnecting after losing its connection
+	relayMinReconnectInterval = time.Second
+	// relayMaxReconnectInterval is the longest the listener waits between reconnection attempts, the wait doubles up to it
+	relayMaxReconnectInterval = time.Minute
+	// relayPingInterval is how often an idle listener checks its connection, so a lost connection is noticed and reestablished
+	relayPingInterval = 90 * time.Second
+)
+
+// runEventListener publishes the events of the other instances of the server to the realtime hub of this one, until the context is done.
+//
+// It takes a context and the URL of the database, and is meant to run in its own goroutine.
+// The events arrive through Postgres LISTEN on realtimeChannel, see publishEvent, the events of this instance are left out.
+// Each event carries only the ID of what it is about, the listener rebuilds its payload.
+// A content_filter_changed event reloads the content filter of this instance instead.
+// The listener holds its own connection and reconnects when it is lost. The events relayed while it is disconnected are lost,
+// clients of this instance don't receive them.
+func (cfg *apiConfig) runEventListener(ctx context.Context, dbURL string) {
+	listener := pq.NewListener(dbURL, relayMinReconnectInterval, relayMaxReconnectInterval, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected:
+			log.Printf("Event listener lost its connection: %v", err)
+		case pq.ListenerEventConnectionAttemptFailed:
+			log.Printf("Event listener couldn't connect: %v", err)
+		case pq.ListenerEventReconnected:
+			log.Println("Event listener reconnected")
+		}
+	})
+	defer listener.Close()
+
+	// Listen waits for the connection, the context can't end it, so it runs on its own goroutine
+	listening := make(chan error, 1)
+	go func() {
+		listening <- listener.Listen(realtimeChannel)
+	}()
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-listening:
+		if err != nil {
+			log.Printf("Couldn't listen for events of other instances: %v", err)
+			return
+		}
+	}
+
+	ping := time.NewTicker(relayPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			go listener.Ping()
+		case notification := <-listener.Notify:
+			// a nil notification follows a reconnection
+			if notification == nil {
+				continue
+			}
+			relay, err := realtime.DecodeRelay([]byte(notification.Extra))
+			if err != nil {
+				log.Printf("Couldn't decode relayed event: %v", err)
+				continue
+			}
+			if relay.Origin == cfg.instanceID {
+				continue
+			}
+			if relay.Type == relayTypeContentFilterChanged {
+				err = cfg.reloadContentFilter(ctx)
+				if err != nil {
+					log.Printf("Couldn't reload content filter: %v", err)
+				}
+				continue
+			}
+			err = cfg.publishRelayed(ctx, relay)
+			if err != nil {
+				log.Printf("Couldn't publish relayed %s event: %v", relay.Type, err)
+			}
+		}
+	}
+}
+
+// publishRelayed publishes an event relayed by another instance to the realtime hub of this one.
+//
+// It takes a context and the relayed event.
+// The payload is rebuilt from the database, as the clients of the other instance received it,
+// an event about something gone since, like a chirp deleted right after it was posted, is left out.
+// Returns an error if the payload can't be rebuilt.
+func (cfg *apiConfig) publishRelayed(ctx context.Context, relay realtime.Relay) error {
+	payload, err := cfg.relayedEventPayload(ctx, relay)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if payload == nil {
+		return nil
+	}
+
+	dat, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	cfg.hub.Publish(realtime.Event{
+		Type:     relay.Type,
+		UserID:   relay.UserID,
+		ActorIDs: relay.ActorIDs,
+		Topics:   relay.Topics,
+		Data:     dat,
+	})
+	return nil
+}
+
+// relayedEventPayload rebuilds the payload of a relayed event from the ID of what it is about.
+//
+// It takes a context and the relayed event.
+// Returns the payload, nil for an event to leave out, or an error if a lookup fails or the type is unknown.
+func (cfg *apiConfig) relayedEventPayload(ctx context.Context, relay realtime.Relay) (any, error) {
+	switch relay.Type {
+	case eventTypeChirp:
+		dbChirp, err := cfg.db.GetChirpById(ctx, relay.SubjectID)
+		if err != nil {
+			return nil, err
+		}
+		if dbChirp.Status != chirpStatusPublished {
+			return nil, nil
+		}
+		chirps := []Chirp{databaseChirpToChirp(dbChirp)}
+		err = cfg.hydrateChirps(ctx, uuid.Nil, chirps)
+		if err != nil {
+			return nil, err
+		}
+		return chirps[0], nil
+	case eventTypeChirpDeleted:
+		return chirpDeletedEvent{ID: relay.SubjectID}, nil
+	case eventTypeNotification:
+		notification, err := cfg.db.GetNotificationByID(ctx, relay.SubjectID)
+		if err != nil {
+			return nil, err
+		}
+		groups, err := cfg.db.GetNotificationGroupsByIDs(ctx, []uuid.UUID{notification.GroupID})
+		if err != nil {
+			return nil, err
+		}
+		if len(groups) == 0 {
+			return nil, nil
+		}
+		actor, err := cfg.db.GetUserByID(ctx, notification.ActorID)
+		if err != nil {
+			return nil, err
+		}
+		return notificationEvent(notification, groups[0], databaseUserToUserSummary(actor)), nil
+	case eventTypeUserUpgraded:
+		user, err := cfg.db.GetUserByID(ctx, relay.SubjectID)
+		if err != nil {
+			return nil, err
+		}
+		return databaseUserToUser(user), nil
+	case eventTypeHiddenUsersChanged:
+		return struct{}{}, nil
+	}
+	return nil, fmt.Errorf("unknown event type %q", relay.Type)
+}
@@ -0,0 +1,47 @@
+-- name: BlockUser :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+    VALUES (
+        $1, 
+        $2, 
+        NOW()
+        )
+    ON CONFLICT (blocker_id, blocked_id) DO NOTHING;
+
+-- name: UnblockUser :exec
+DELETE FROM blocks 
+    WHERE blocker_id = $1 
+        AND blocked_id = $2;
+
+-- name: ListBlockedUsers :many
+SELECT sqlc.embed(users), blocks.created_at AS blocked_at
+    FROM blocks 
+    JOIN users ON users.id = blocks.blocked_id
+    WHERE blocks.blocker_id = sqlc.arg('user_id')
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (blocks.created_at, users.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY blocks.created_at DESC, users.id DESC
+    LIMIT sqlc.arg('limit');
+
+-- name: IsBlockedBetween :one
+SELECT blocked_between(sqlc.arg('user_id')::uuid, sqlc.arg('other_user_id')::uuid)::boolean AS blocked;
+
+-- name: GetBlockedUserIDs :many
+-- the users among user_ids who blocked user_id or were blocked by them
+SELECT users.id
+    FROM users 
+    WHERE users.id = ANY(sqlc.arg('user_ids')::uuid[])
+        AND blocked_between(users.id, sqlc.arg('user_id')::uuid);
+
+-- name: GetHiddenUserIDs :many
+-- the users whose chirps are hidden from user_id, see hidden_from
+SELECT blocked_id AS user_id
+    FROM blocks 
+    WHERE blocker_id = sqlc.arg('user_id')
+UNION
+SELECT blocker_id
+    FROM blocks 
+    WHERE blocked_id = sqlc.arg('user_id')
+UNION
+SELECT muted_id
+    FROM mutes 
+    WHERE muter_id = sqlc.arg('user_id');
@@ -0,0 +1,31 @@
+-- name: BookmarkChirp :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+    VALUES (
+        $1, 
+        $2, 
+        NOW()
+        )
+    ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnbookmarkChirp :exec
+DELETE FROM bookmarks 
+    WHERE user_id = $1 
+        AND chirp_id = $2;
+
+-- name: ListBookmarks :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at
+    FROM bookmarks 
+    JOIN chirps ON chirps.id = bookmarks.chirp_id
+    WHERE bookmarks.user_id = sqlc.arg('user_id')
+        AND chirps.status = 'published'
+        AND NOT hidden_from(chirps.user_id, sqlc.arg('user_id'))
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (bookmarks.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY bookmarks.created_at DESC, chirps.id DESC
+    LIMIT sqlc.arg('limit');
+
+-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id
+    FROM bookmarks 
+    WHERE user_id = $1 
+        AND chirp_id = ANY($2::uuid[]);
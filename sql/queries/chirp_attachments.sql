@@ -0,0 +1,23 @@
+-- name: CreateChirpAttachment :exec
+INSERT INTO chirp_attachments (id, created_at, chirp_id, ordinal, content_type, storage_key, width, height, thumbnail_key, thumbnail_width, thumbnail_height, byte_size, alt_text)
+    VALUES (
+        $1, 
+        NOW(), 
+        $2, 
+        $3, 
+        $4,
+        $5,
+        $6,
+        $7,
+        $8,
+        $9,
+        $10,
+        $11,
+        $12
+        );
+
+-- name: GetAttachmentsForChirps :many
+SELECT * 
+    FROM chirp_attachments 
+    WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+    ORDER BY chirp_id, ordinal;
@@ -0,0 +1,25 @@
+-- name: LikeChirp :exec
+INSERT INTO chirp_likes (user_id, chirp_id, created_at)
+    VALUES (
+        $1, 
+        $2, 
+        NOW()
+        )
+    ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnlikeChirp :exec
+DELETE FROM chirp_likes 
+    WHERE user_id = $1 
+        AND chirp_id = $2;
+
+-- name: CountLikesForChirps :many
+SELECT chirp_id, COUNT(*) AS like_count
+    FROM chirp_likes 
+    WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+    GROUP BY chirp_id;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id
+    FROM chirp_likes 
+    WHERE user_id = sqlc.arg('user_id') 
+        AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
@@ -1,23 +1,222 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, reply_to_id, status, publish_at)
     VALUES (
         gen_random_uuid(), 
         NOW(), 
         NOW(), 
         $1, 
-        $2
+        $2,
+        $3,
+        $4,
+        $5
         )
     RETURNING *;
 
--- name: GetAllChirps :many
+-- name: ListChirpsAsc :many
+SELECT *
+    FROM chirps 
+    WHERE status = 'published'
+        AND NOT hidden_from(user_id, sqlc.arg('viewer_id'))
+        AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (created_at, id) > (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY created_at ASC, id ASC
+    LIMIT sqlc.arg('limit');
+
+-- name: ListChirpsDesc :many
 SELECT *
     FROM chirps 
-    ORDER BY created_at ASC;
+    WHERE status = 'published'
+        AND NOT hidden_from(user_id, sqlc.arg('viewer_id'))
+        AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY created_at DESC, id DESC
+    LIMIT sqlc.arg('limit');
 
 -- name: GetChirpById :one
 SELECT *
     FROM chirps 
     WHERE id = $1;
 
+-- name: GetChirpByIdForUpdate :one
+SELECT *
+    FROM chirps 
+    WHERE id = $1
+    FOR UPDATE;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps 
+    SET body = $2,
+    status = $3,
+    updated_at = NOW()
+    WHERE id = $1
+    RETURNING *;
+
 -- name: DeleteChirpById :exec
 DELETE FROM chirps WHERE id = $1;
+
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps), ranked.rank, 
+    ts_headline('english', chirps.body, to_tsquery('english', sqlc.arg('query')), 
+        E'StartSel=\uE000, StopSel=\uE001, MaxFragments=2, MaxWords=20, MinWords=5') AS snippet
+    FROM (
+        SELECT chirps.id,
+            ts_rank(chirp_search.search_vector, to_tsquery('english', sqlc.arg('query'))) AS rank
+        FROM chirp_search 
+        JOIN chirps ON chirps.id = chirp_search.chirp_id
+        WHERE chirp_search.search_vector @@ to_tsquery('english', sqlc.arg('query'))
+            AND chirps.status = 'published'
+            AND NOT hidden_from(chirps.user_id, sqlc.arg('viewer_id'))
+            AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id'))
+    ) AS ranked
+    JOIN chirps ON chirps.id = ranked.id
+    WHERE sqlc.narg('cursor_rank')::real IS NULL 
+        OR (ranked.rank, chirps.created_at, chirps.id) < (sqlc.narg('cursor_rank'), sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    ORDER BY ranked.rank DESC, chirps.created_at DESC, chirps.id DESC
+    LIMIT sqlc.arg('limit');
+
+-- name: GetThreadAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT chirps.id, chirps.reply_to_id, 0::int AS depth
+        FROM chirps 
+        WHERE chirps.id = sqlc.arg('chirp_id')
+    UNION ALL
+    SELECT chirps.id, chirps.reply_to_id, ancestors.depth + 1
+        FROM chirps 
+        JOIN ancestors ON chirps.id = ancestors.reply_to_id
+        WHERE ancestors.depth < sqlc.arg('max_depth')::int
+)
+SELECT chirps.*
+    FROM chirps 
+    JOIN ancestors ON chirps.id = ancestors.id
+    WHERE ancestors.depth > 0
+        AND chirps.status = 'published'
+        AND NOT hidden_from(chirps.user_id, sqlc.arg('viewer_id'))
+    ORDER BY ancestors.depth DESC;
+
+-- name: GetThreadReplies :many
+WITH RECURSIVE top_replies AS (
+    SELECT chirps.id
+        FROM chirps 
+        WHERE chirps.reply_to_id = sqlc.arg('chirp_id')
+            AND chirps.status = 'published'
+            AND NOT hidden_from(chirps.user_id, sqlc.arg('viewer_id'))
+            AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+                OR (chirps.created_at, chirps.id) > (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+        ORDER BY chirps.created_at ASC, chirps.id ASC
+        LIMIT sqlc.arg('limit')
+), descendants AS (
+    SELECT top_replies.id, 1::int AS depth
+        FROM top_replies
+    UNION ALL
+    SELECT chirps.id, descendants.depth + 1
+        FROM chirps 
+        JOIN descendants ON chirps.reply_to_id = descendants.id
+        WHERE descendants.depth < sqlc.arg('max_depth')::int
+            AND chirps.status = 'published'
+            AND NOT hidden_from(chirps.user_id, sqlc.arg('viewer_id'))
+)
+SELECT sqlc.embed(chirps), descendants.depth
+    FROM chirps 
+    JOIN descendants ON chirps.id = descendants.id
+    ORDER BY descendants.depth ASC, chirps.created_at ASC, chirps.id ASC;
+
+-- name: CountRepliesForChirps :many
+SELECT reply_to_id, COUNT(*) AS reply_count
+    FROM chirps 
+    WHERE reply_to_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+        AND status = 'published'
+    GROUP BY reply_to_id;
+
+-- name: GetChirpsByIDs :many
+SELECT *
+    FROM chirps 
+    WHERE id = ANY(sqlc.arg('ids')::uuid[])
+        AND status = 'published'
+        AND NOT hidden_from(user_id, sqlc.arg('viewer_id'));
+
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, repost_kind, repost_of_id)
+    VALUES (
+        gen_random_uuid(), 
+        NOW(), 
+        NOW(), 
+        '', 
+        $1,
+        'rechirp',
+        $2
+        )
+    ON CONFLICT (user_id, repost_of_id) WHERE repost_kind = 'rechirp' DO NOTHING
+    RETURNING *;
+
+-- name: GetRechirp :one
+SELECT *
+    FROM chirps 
+    WHERE user_id = $1 
+        AND repost_of_id = $2 
+        AND repost_kind = 'rechirp';
+
+-- name: DeleteRechirp :many
+DELETE FROM chirps 
+    WHERE user_id = $1 
+        AND repost_of_id = $2 
+        AND repost_kind = 'rechirp'
+    RETURNING *;
+
+-- name: CreateQuote :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, repost_kind, repost_of_id, status)
+    VALUES (
+        gen_random_uuid(), 
+        NOW(), 
+        NOW(), 
+        $1, 
+        $2,
+        'quote',
+        $3,
+        $4
+        )
+    RETURNING *;
+
+-- name: CountRepostsForChirps :many
+SELECT repost_of_id, 
+    COUNT(*) FILTER (WHERE repost_kind = 'rechirp') AS rechirp_count,
+    COUNT(*) FILTER (WHERE repost_kind = 'quote') AS quote_count
+    FROM chirps 
+    WHERE repost_of_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+        AND status = 'published'
+    GROUP BY repost_of_id;
+
+-- name: ListTimeline :many
+SELECT chirps.*
+    FROM chirps 
+    JOIN (
+        SELECT follows.followee_id AS author_id
+            FROM follows 
+            WHERE follows.follower_id = sqlc.arg('user_id')
+        UNION ALL
+        SELECT sqlc.arg('user_id')::uuid
+    ) AS authors ON chirps.user_id = authors.author_id
+    WHERE chirps.status = 'published'
+        AND NOT hidden_from(chirps.user_id, sqlc.arg('user_id'))
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY chirps.created_at DESC, chirps.id DESC
+    LIMIT sqlc.arg('limit');
+
+-- name: ListChirpsForModeration :many
+SELECT *
+    FROM chirps 
+    WHERE status = 'pending_review'
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (created_at, id) > (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY created_at ASC, id ASC
+    LIMIT sqlc.arg('limit');
+
+-- name: UpdateChirpStatus :one
+UPDATE chirps 
+    SET status = sqlc.arg('status'),
+    updated_at = NOW()
+    WHERE id = sqlc.arg('id')
+        AND status = sqlc.arg('from_status')
+    RETURNING *;
@@ -0,0 +1,21 @@
+-- name: ListContentFilterRules :many
+SELECT * 
+    FROM content_filter_rules 
+    ORDER BY word;
+
+-- name: UpsertContentFilterRule :one
+INSERT INTO content_filter_rules (id, created_at, updated_at, word, action)
+    VALUES (
+        gen_random_uuid(), 
+        NOW(), 
+        NOW(), 
+        $1, 
+        $2
+        )
+    ON CONFLICT (word) DO UPDATE 
+        SET action = EXCLUDED.action, 
+        updated_at = NOW()
+    RETURNING *;
+
+-- name: DeleteContentFilterRule :execrows
+DELETE FROM content_filter_rules WHERE id = $1;
@@ -0,0 +1,83 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, is_group)
+    VALUES (
+        gen_random_uuid(), 
+        NOW(), 
+        NOW(), 
+        $1
+        )
+    RETURNING *;
+
+-- name: AddConversationParticipants :exec
+INSERT INTO conversation_participants (conversation_id, user_id, created_at)
+    SELECT sqlc.arg('conversation_id')::uuid, participants.user_id, NOW()
+        FROM unnest(sqlc.arg('user_ids')::uuid[]) AS participants(user_id);
+
+-- name: GetConversationForUser :one
+SELECT conversations.*
+    FROM conversations 
+    JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+    WHERE conversations.id = sqlc.arg('id')
+        AND conversation_participants.user_id = sqlc.arg('user_id');
+
+-- name: LockDirectConversation :exec
+-- holds other transactions starting a 1:1 conversation between the same two users until this one ends,
+-- so two requests can't both find none and create one each
+SELECT pg_advisory_xact_lock(hashtextextended(
+    LEAST(sqlc.arg('user_id')::uuid, sqlc.arg('other_user_id')::uuid)::text || GREATEST(sqlc.arg('user_id')::uuid, sqlc.arg('other_user_id')::uuid)::text, 0));
+
+-- name: FindDirectConversation :one
+SELECT conversations.*
+    FROM conversations 
+    WHERE NOT conversations.is_group
+        AND EXISTS (
+            SELECT 1 
+                FROM conversation_participants 
+                WHERE conversation_participants.conversation_id = conversations.id 
+                    AND conversation_participants.user_id = sqlc.arg('user_id')
+            )
+        AND EXISTS (
+            SELECT 1 
+                FROM conversation_participants 
+                WHERE conversation_participants.conversation_id = conversations.id 
+                    AND conversation_participants.user_id = sqlc.arg('other_user_id')
+            );
+
+-- name: ListConversations :many
+SELECT conversations.*
+    FROM conversations 
+    JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+    WHERE conversation_participants.user_id = sqlc.arg('user_id')
+        AND (sqlc.narg('cursor_updated_at')::timestamp IS NULL 
+            OR (conversations.updated_at, conversations.id) < (sqlc.narg('cursor_updated_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY conversations.updated_at DESC, conversations.id DESC
+    LIMIT sqlc.arg('limit');
+
+-- name: GetConversationParticipants :many
+SELECT conversation_participants.conversation_id, sqlc.embed(users), conversation_participants.last_read_at
+    FROM conversation_participants 
+    JOIN users ON users.id = conversation_participants.user_id
+    WHERE conversation_participants.conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+    ORDER BY conversation_participants.conversation_id, conversation_participants.created_at, users.id;
+
+-- name: CountUnreadMessages :many
+SELECT messages.conversation_id, COUNT(*) AS unread_count
+    FROM messages 
+    JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id
+        AND conversation_participants.user_id = sqlc.arg('user_id')
+    WHERE messages.conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+        AND messages.sender_id <> sqlc.arg('user_id')
+        AND (conversation_participants.last_read_at IS NULL 
+            OR messages.created_at > conversation_participants.last_read_at)
+    GROUP BY messages.conversation_id;
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_participants 
+    SET last_read_at = GREATEST(last_read_at, NOW())
+    WHERE conversation_id = $1
+        AND user_id = $2;
+
+-- name: TouchConversation :exec
+UPDATE conversations 
+    SET updated_at = NOW()
+    WHERE id = $1;
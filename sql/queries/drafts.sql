@@ -0,0 +1,47 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, created_at, updated_at, user_id, body, reply_to_id)
+    VALUES (
+        gen_random_uuid(), 
+        NOW(), 
+        NOW(), 
+        $1, 
+        $2,
+        $3
+        )
+    RETURNING *;
+
+-- name: ListDrafts :many
+SELECT *
+    FROM drafts 
+    WHERE user_id = sqlc.arg('user_id')
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY created_at DESC, id DESC
+    LIMIT sqlc.arg('limit');
+
+-- name: GetDraft :one
+SELECT *
+    FROM drafts 
+    WHERE id = $1 
+        AND user_id = $2;
+
+-- name: GetDraftForUpdate :one
+SELECT *
+    FROM drafts 
+    WHERE id = $1 
+        AND user_id = $2
+    FOR UPDATE;
+
+-- name: UpdateDraft :one
+UPDATE drafts 
+    SET body = $3,
+    reply_to_id = $4,
+    updated_at = NOW()
+    WHERE id = $1 
+        AND user_id = $2
+    RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts 
+    WHERE id = $1 
+        AND user_id = $2;
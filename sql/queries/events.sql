@@ -0,0 +1,3 @@
+-- name: NotifyEvent :exec
+-- sends an event to the instances listening on the channel, Postgres delivers it when the transaction commits
+SELECT pg_notify(sqlc.arg(channel)::text, sqlc.arg(payload)::text);
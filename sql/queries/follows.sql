@@ -0,0 +1,38 @@
+-- name: FollowUser :exec
+INSERT INTO follows (follower_id, followee_id, created_at)
+    VALUES (
+        $1, 
+        $2, 
+        NOW()
+        )
+    ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+-- name: UnfollowUser :exec
+DELETE FROM follows 
+    WHERE follower_id = $1 
+        AND followee_id = $2;
+
+-- name: ListFollowers :many
+SELECT sqlc.embed(users), follows.created_at AS followed_at
+    FROM follows 
+    JOIN users ON users.id = follows.follower_id
+    WHERE follows.followee_id = sqlc.arg('user_id')
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (follows.created_at, users.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY follows.created_at DESC, users.id DESC
+    LIMIT sqlc.arg('limit');
+
+-- name: ListFollowing :many
+SELECT sqlc.embed(users), follows.created_at AS followed_at
+    FROM follows 
+    JOIN users ON users.id = follows.followee_id
+    WHERE follows.follower_id = sqlc.arg('user_id')
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (follows.created_at, users.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY follows.created_at DESC, users.id DESC
+    LIMIT sqlc.arg('limit');
+
+-- name: DeleteFollowsBetween :exec
+DELETE FROM follows 
+    WHERE (follower_id = $1 AND followee_id = $2)
+        OR (follower_id = $2 AND followee_id = $1);
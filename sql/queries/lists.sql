@@ -0,0 +1,78 @@
+-- name: CreateList :one
+INSERT INTO lists (id, created_at, updated_at, user_id, name, description, is_private)
+    VALUES (
+        gen_random_uuid(), 
+        NOW(), 
+        NOW(), 
+        $1, 
+        $2,
+        $3,
+        $4
+        )
+    RETURNING *;
+
+-- name: GetList :one
+SELECT *
+    FROM lists 
+    WHERE id = $1;
+
+-- name: ListListsForUser :many
+SELECT *
+    FROM lists 
+    WHERE user_id = sqlc.arg('user_id')
+        AND (NOT is_private OR sqlc.arg('include_private')::boolean)
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY created_at DESC, id DESC
+    LIMIT sqlc.arg('limit');
+
+-- name: UpdateList :one
+UPDATE lists 
+    SET name = $3,
+    description = $4,
+    is_private = $5,
+    updated_at = NOW()
+    WHERE id = $1 
+        AND user_id = $2
+    RETURNING *;
+
+-- name: DeleteList :execrows
+DELETE FROM lists 
+    WHERE id = $1 
+        AND user_id = $2;
+
+-- name: AddListMember :exec
+INSERT INTO list_members (list_id, user_id, created_at)
+    VALUES (
+        $1, 
+        $2, 
+        NOW()
+        )
+    ON CONFLICT (list_id, user_id) DO NOTHING;
+
+-- name: RemoveListMember :exec
+DELETE FROM list_members 
+    WHERE list_id = $1 
+        AND user_id = $2;
+
+-- name: ListListMembers :many
+SELECT sqlc.embed(users), list_members.created_at AS added_at
+    FROM list_members 
+    JOIN users ON users.id = list_members.user_id
+    WHERE list_members.list_id = sqlc.arg('list_id')
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (list_members.created_at, users.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY list_members.created_at DESC, users.id DESC
+    LIMIT sqlc.arg('limit');
+
+-- name: ListListTimeline :many
+SELECT chirps.*
+    FROM chirps 
+    JOIN list_members ON chirps.user_id = list_members.user_id
+    WHERE list_members.list_id = sqlc.arg('list_id')
+        AND chirps.status = 'published'
+        AND NOT hidden_from(chirps.user_id, sqlc.arg('viewer_id'))
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY chirps.created_at DESC, chirps.id DESC
+    LIMIT sqlc.arg('limit');
@@ -0,0 +1,19 @@
+-- name: CreateMessage :one
+INSERT INTO messages (id, created_at, conversation_id, sender_id, body)
+    VALUES (
+        gen_random_uuid(), 
+        NOW(), 
+        $1, 
+        $2,
+        $3
+        )
+    RETURNING *;
+
+-- name: ListMessages :many
+SELECT *
+    FROM messages 
+    WHERE conversation_id = sqlc.arg('conversation_id')
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY created_at DESC, id DESC
+    LIMIT sqlc.arg('limit');
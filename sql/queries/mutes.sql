@@ -0,0 +1,23 @@
+-- name: MuteUser :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+    VALUES (
+        $1, 
+        $2, 
+        NOW()
+        )
+    ON CONFLICT (muter_id, muted_id) DO NOTHING;
+
+-- name: UnmuteUser :exec
+DELETE FROM mutes 
+    WHERE muter_id = $1 
+        AND muted_id = $2;
+
+-- name: ListMutedUsers :many
+SELECT sqlc.embed(users), mutes.created_at AS muted_at
+    FROM mutes 
+    JOIN users ON users.id = mutes.muted_id
+    WHERE mutes.muter_id = sqlc.arg('user_id')
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (mutes.created_at, users.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY mutes.created_at DESC, users.id DESC
+    LIMIT sqlc.arg('limit');
@@ -0,0 +1,88 @@
+-- name: CreateNotifications :many
+-- notifies every user in user_ids who wants to be notified of the type,
+-- except the actor themselves, users who blocked, were blocked by or muted the actor and users already notified,
+-- each notification joins the group of its type and subject chirp of the day, which is created for the first one,
+-- the update that changes nothing on conflict returns the group already there
+WITH recipients AS (
+    SELECT users.id 
+        FROM users 
+        WHERE users.id = ANY(sqlc.arg('user_ids')::uuid[])
+            AND users.id <> sqlc.arg('actor_id')
+            AND NOT hidden_from(sqlc.arg('actor_id'), users.id)
+            AND CASE sqlc.arg('type')::text
+                WHEN 'like' THEN users.notify_likes
+                WHEN 'reply' THEN users.notify_replies
+                WHEN 'follow' THEN users.notify_follows
+                WHEN 'mention' THEN users.notify_mentions
+                ELSE FALSE
+                END
+            AND NOT EXISTS (
+                SELECT 1 
+                    FROM notifications 
+                    WHERE notifications.user_id = users.id
+                        AND notifications.type = sqlc.arg('type')::text
+                        AND notifications.actor_id = sqlc.arg('actor_id')
+                        AND notifications.chirp_id IS NOT DISTINCT FROM sqlc.narg('chirp_id')::uuid
+            )
+), recipient_groups AS (
+    INSERT INTO notification_groups (id, created_at, user_id, type, subject_chirp_id, day)
+        SELECT gen_random_uuid(), NOW(), recipients.id, sqlc.arg('type')::text, sqlc.narg('subject_chirp_id')::uuid, NOW()::date
+            FROM recipients 
+        ON CONFLICT (user_id, type, COALESCE(subject_chirp_id, '00000000-0000-0000-0000-000000000000'), day) 
+            DO UPDATE SET day = EXCLUDED.day
+        RETURNING id, user_id
+)
+INSERT INTO notifications (id, created_at, user_id, actor_id, type, chirp_id, group_id)
+    SELECT gen_random_uuid(), NOW(), recipient_groups.user_id, sqlc.arg('actor_id')::uuid, sqlc.arg('type')::text, sqlc.narg('chirp_id')::uuid, recipient_groups.id
+        FROM recipient_groups 
+    ON CONFLICT DO NOTHING
+    RETURNING *;
+
+-- name: GetNotificationByID :one
+SELECT * FROM notifications WHERE id = $1;
+
+-- name: GetNotificationGroupsByIDs :many
+SELECT * 
+    FROM notification_groups 
+    WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: ListNotificationGroups :many
+-- a page of the notification groups of a user, ordered by the time each group began, with the latest actors of each group
+SELECT notification_groups.id, notification_groups.created_at, notification_groups.type, notification_groups.subject_chirp_id,
+        members.latest_at, members.actor_count, members.unread_count, members.actor_ids
+    FROM notification_groups 
+    CROSS JOIN LATERAL (
+        SELECT MAX(notifications.created_at)::timestamp AS latest_at,
+            COUNT(*) AS actor_count,
+            COUNT(*) FILTER (WHERE notifications.read_at IS NULL) AS unread_count,
+            (array_agg(notifications.actor_id ORDER BY notifications.created_at DESC, notifications.id DESC))[1:3]::uuid[] AS actor_ids
+            FROM notifications 
+            WHERE notifications.group_id = notification_groups.id
+    ) AS members
+    WHERE notification_groups.user_id = sqlc.arg('user_id')
+        AND members.actor_count > 0
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (notification_groups.created_at, notification_groups.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY notification_groups.created_at DESC, notification_groups.id DESC
+    LIMIT sqlc.arg('limit');
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) AS unread_count
+    FROM notifications 
+    WHERE user_id = $1 
+        AND read_at IS NULL;
+
+-- name: MarkNotificationGroupRead :execrows
+-- marks read the notifications of a group, see ListNotificationGroups
+UPDATE notifications 
+    SET read_at = COALESCE(notifications.read_at, NOW())
+    FROM notification_groups 
+    WHERE notification_groups.id = sqlc.arg('id')
+        AND notification_groups.user_id = sqlc.arg('user_id')
+        AND notifications.group_id = notification_groups.id;
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications 
+    SET read_at = NOW()
+    WHERE user_id = $1 
+        AND read_at IS NULL;
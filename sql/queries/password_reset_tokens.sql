@@ -0,0 +1,31 @@
+-- name: CreatePasswordResetToken :exec
+-- a token can be used for an hour, the times are all taken from the database so they compare with NOW() in UsePasswordResetToken
+INSERT INTO password_reset_tokens (token_hash, user_id, created_at, expires_at)
+    VALUES (
+        $1, 
+        $2, 
+        NOW(), 
+        NOW() + interval '1 hour'
+        );
+
+-- name: CountRecentPasswordResetTokens :one
+-- counts the tokens created for the user in the last hour
+SELECT COUNT(*) 
+    FROM password_reset_tokens 
+    WHERE user_id = $1 
+        AND created_at > NOW() - interval '1 hour';
+
+-- name: UsePasswordResetToken :one
+-- uses up the token if it is still valid, so it can't be used twice even by concurrent requests
+UPDATE password_reset_tokens 
+    SET used_at = NOW()
+    WHERE token_hash = $1 
+        AND used_at IS NULL 
+        AND expires_at > NOW()
+    RETURNING user_id;
+
+-- name: UsePasswordResetTokensOfUser :exec
+UPDATE password_reset_tokens 
+    SET used_at = NOW()
+    WHERE user_id = $1 
+        AND used_at IS NULL;
@@ -0,0 +1,42 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, created_at, closes_at)
+    VALUES (
+        $1, 
+        NOW(), 
+        $2
+        );
+
+-- name: CreatePollOptions :exec
+INSERT INTO poll_options (id, chirp_id, position, label)
+    SELECT gen_random_uuid(), sqlc.arg('chirp_id'), options.position, options.label
+        FROM unnest(sqlc.arg('labels')::text[]) WITH ORDINALITY AS options(label, position);
+
+-- name: GetPoll :one
+SELECT * 
+    FROM polls 
+    WHERE chirp_id = $1;
+
+-- name: CreatePollVote :execrows
+-- nothing is inserted once the poll has closed, closes_at is stored in UTC by the server,
+-- so it is compared with the time of the server in UTC rather than with NOW()
+INSERT INTO poll_votes (chirp_id, user_id, option_id, created_at)
+    SELECT polls.chirp_id, sqlc.arg('user_id'), sqlc.arg('option_id'), NOW()
+        FROM polls 
+        WHERE polls.chirp_id = sqlc.arg('chirp_id')
+            AND polls.closes_at > sqlc.arg('now')::timestamp;
+
+-- name: GetPollsForChirps :many
+SELECT poll_options.chirp_id, polls.closes_at, poll_options.id, poll_options.position, poll_options.label, 
+    COUNT(poll_votes.user_id) AS vote_count
+    FROM poll_options 
+    JOIN polls ON polls.chirp_id = poll_options.chirp_id
+    LEFT JOIN poll_votes ON poll_votes.option_id = poll_options.id
+    WHERE poll_options.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+    GROUP BY poll_options.id, polls.closes_at
+    ORDER BY poll_options.chirp_id, poll_options.position;
+
+-- name: GetPollVotesForUser :many
+SELECT chirp_id, option_id 
+    FROM poll_votes 
+    WHERE user_id = sqlc.arg('user_id')
+        AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
@@ -23,4 +23,11 @@ UPDATE refresh_tokens
     SET revoked_at = NOW(),
     updated_at = NOW()
     WHERE token = $1
-    RETURNING *;
\ No newline at end of file
+    RETURNING *;
+
+-- name: RevokeRefreshTokensOfUser :exec
+UPDATE refresh_tokens 
+    SET revoked_at = NOW(),
+    updated_at = NOW()
+    WHERE user_id = $1 
+        AND revoked_at IS NULL;
@@ -0,0 +1,55 @@
+-- name: ListScheduledChirps :many
+SELECT *
+    FROM chirps 
+    WHERE user_id = sqlc.arg('user_id')
+        AND status = 'scheduled'
+        AND (sqlc.narg('cursor_publish_at')::timestamp IS NULL 
+            OR (publish_at, id) > (sqlc.narg('cursor_publish_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY publish_at ASC, id ASC
+    LIMIT sqlc.arg('limit');
+
+-- name: RescheduleChirp :one
+UPDATE chirps 
+    SET publish_at = sqlc.arg('publish_at'),
+    updated_at = NOW()
+    WHERE id = sqlc.arg('id')
+        AND user_id = sqlc.arg('user_id')
+        AND status = 'scheduled'
+    RETURNING *;
+
+-- name: ShiftPollOfScheduledChirp :exec
+-- moves the closing time of the poll of a scheduled chirp along with its publish time, so the poll keeps its duration,
+-- it must run before RescheduleChirp changes the publish time
+UPDATE polls 
+    SET closes_at = polls.closes_at + (sqlc.arg('publish_at')::timestamp - chirps.publish_at)
+    FROM chirps 
+    WHERE polls.chirp_id = chirps.id
+        AND chirps.id = sqlc.arg('id')
+        AND chirps.user_id = sqlc.arg('user_id')
+        AND chirps.status = 'scheduled';
+
+-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps 
+    WHERE id = $1 
+        AND user_id = $2 
+        AND status = 'scheduled';
+
+-- name: ClaimDueChirps :many
+-- publish_at is stored in UTC by the server, so it is compared with the time of the server in UTC rather than with NOW(),
+-- which follows the time zone of the database session
+SELECT *
+    FROM chirps 
+    WHERE status = 'scheduled'
+        AND publish_at <= sqlc.arg('now')::timestamp
+    ORDER BY publish_at ASC, id ASC
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED;
+
+-- name: PublishScheduledChirp :one
+-- created_at becomes the time of publishing, so the chirp shows up at the top of timelines
+UPDATE chirps 
+    SET status = 'published',
+    created_at = NOW(),
+    updated_at = NOW()
+    WHERE id = $1
+    RETURNING *;
@@ -0,0 +1,48 @@
+-- name: CreateTags :exec
+INSERT INTO tags (name, created_at, trend_score, trend_updated_at)
+    SELECT unnest(sqlc.arg('names')::text[]), 
+        NOW(), 
+        0, 
+        NOW()
+    ON CONFLICT (name) DO NOTHING;
+
+-- name: AddChirpTags :many
+INSERT INTO chirp_tags (chirp_id, tag)
+    SELECT sqlc.arg('chirp_id')::uuid, 
+        unnest(sqlc.arg('tags')::text[])
+    ON CONFLICT (chirp_id, tag) DO NOTHING
+    RETURNING tag;
+
+-- name: DeleteChirpTagsExcept :exec
+DELETE FROM chirp_tags 
+    WHERE chirp_id = sqlc.arg('chirp_id') 
+        AND NOT (tag = ANY(sqlc.arg('tags')::text[]));
+
+-- name: BumpTagTrends :exec
+UPDATE tags 
+    SET trend_score = trend_score 
+            * power(0.5, extract(epoch FROM NOW() - trend_updated_at)::float8 / sqlc.arg('half_life_seconds')::float8) 
+            + 1,
+    trend_updated_at = NOW()
+    WHERE name = ANY(sqlc.arg('names')::text[]);
+
+-- name: ListTrendingTags :many
+SELECT name, 
+    (trend_score 
+        * power(0.5, extract(epoch FROM NOW() - trend_updated_at)::float8 / sqlc.arg('half_life_seconds')::float8))::float8 AS score
+    FROM tags 
+    WHERE trend_updated_at > NOW() - make_interval(secs => sqlc.arg('window_seconds')::float8)
+    ORDER BY score DESC, name ASC
+    LIMIT sqlc.arg('limit');
+
+-- name: ListChirpsForTag :many
+SELECT chirps.*
+    FROM chirps 
+    JOIN chirp_tags ON chirp_tags.chirp_id = chirps.id
+    WHERE chirp_tags.tag = sqlc.arg('tag')
+        AND chirps.status = 'published'
+        AND NOT hidden_from(chirps.user_id, sqlc.arg('viewer_id'))
+        AND (sqlc.narg('cursor_created_at')::timestamp IS NULL 
+            OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+    ORDER BY chirps.created_at DESC, chirps.id DESC
+    LIMIT sqlc.arg('limit');
@@ -1,12 +1,16 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password, is_chirpy_red)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url)
     VALUES (
         gen_random_uuid(), 
         NOW(), 
         NOW(), 
         $1, 
         $2,
-        false
+        false,
+        $3,
+        $4,
+        $5,
+        $6
         )
     RETURNING *;
 
@@ -20,10 +24,32 @@ SELECT *
     FROM users 
     WHERE id = $1;
 
+-- name: GetUsersByIDs :many
+SELECT * 
+    FROM users 
+    WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: GetUsersByHandles :many
+SELECT * 
+    FROM users 
+    WHERE lower(handle) = ANY(sqlc.arg('handles')::text[]);
+
+-- name: GetUserProfileByHandle :one
+SELECT id, created_at, handle, display_name, bio, avatar_url, is_chirpy_red,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id AND chirps.status = 'published') AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count
+    FROM users 
+    WHERE lower(handle) = lower(sqlc.arg('handle'));
+
 -- name: UpdateUserData :one
 UPDATE users 
     SET email = $2,
     hashed_password = $3,
+    handle = $4,
+    display_name = $5,
+    bio = $6,
+    avatar_url = $7,
     updated_at = NOW()
     WHERE id = $1
     RETURNING *;
@@ -40,4 +66,20 @@ UPDATE users
     SET is_chirpy_red = false,
     updated_at = NOW()
     WHERE id = $1
-    RETURNING *;
\ No newline at end of file
+    RETURNING *;
+
+-- name: UpdateNotificationPreferences :one
+UPDATE users 
+    SET notify_likes = $1,
+    notify_replies = $2,
+    notify_follows = $3,
+    notify_mentions = $4,
+    updated_at = NOW()
+    WHERE id = $5
+    RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users 
+    SET hashed_password = $2,
+    updated_at = NOW()
+    WHERE id = $1;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Step 1: Add a generated full-text search document for every chirp
+ALTER TABLE chirps 
+    ADD COLUMN search_vector tsvector 
+        GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+
+-- Step 2: Index it for the @@ operator
+CREATE INDEX chirps_search_vector_idx 
+    ON chirps USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX chirps_search_vector_idx;
+ALTER TABLE chirps DROP COLUMN search_vector;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Every edit of a chirp keeps the body it replaced.
+-- created_at is when that body was written, replaced_at is when it was edited away.
+CREATE TABLE chirp_revisions (
+    id UUID     PRIMARY KEY,
+    created_at  TIMESTAMP NOT NULL,
+    replaced_at TIMESTAMP NOT NULL,
+    chirp_id UUID NOT NULL 
+        REFERENCES chirps(id) ON DELETE CASCADE,
+    body        TEXT NOT NULL
+);
+
+CREATE INDEX chirp_revisions_chirp_id_idx 
+    ON chirp_revisions (chirp_id, replaced_at);
+
+-- +goose Down
+DROP TABLE chirp_revisions;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Replies keep their place when the chirp they answer is deleted.
+ALTER TABLE chirps 
+    ADD COLUMN reply_to_id UUID 
+        REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE INDEX chirps_reply_to_id_idx 
+    ON chirps (reply_to_id, created_at, id);
+
+-- +goose Down
+DROP INDEX chirps_reply_to_id_idx;
+ALTER TABLE chirps DROP COLUMN reply_to_id;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE chirp_likes (
+    user_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL 
+        REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at  TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX chirp_likes_chirp_id_idx 
+    ON chirp_likes (chirp_id);
+
+-- +goose Down
+DROP TABLE chirp_likes;
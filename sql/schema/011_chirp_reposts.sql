@@ -0,0 +1,24 @@
+-- +goose Up
+-- A rechirp reposts a chirp as-is, a quote chirp reposts it with a body of its own.
+-- When the original is deleted the repost stays as a tombstone, with repost_of_id set to NULL.
+ALTER TABLE chirps 
+    ADD COLUMN repost_kind TEXT 
+        CHECK (repost_kind IN ('rechirp', 'quote'));
+
+ALTER TABLE chirps 
+    ADD COLUMN repost_of_id UUID 
+        REFERENCES chirps(id) ON DELETE SET NULL;
+
+-- A user can rechirp a chirp only once
+CREATE UNIQUE INDEX chirps_rechirp_unique_idx 
+    ON chirps (user_id, repost_of_id) 
+    WHERE repost_kind = 'rechirp';
+
+CREATE INDEX chirps_repost_of_id_idx 
+    ON chirps (repost_of_id);
+
+-- +goose Down
+DROP INDEX chirps_repost_of_id_idx;
+DROP INDEX chirps_rechirp_unique_idx;
+ALTER TABLE chirps DROP COLUMN repost_of_id;
+ALTER TABLE chirps DROP COLUMN repost_kind;
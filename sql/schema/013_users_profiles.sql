@@ -0,0 +1,28 @@
+-- +goose Up
+-- Step 1: Add the new columns, the handle as nullable
+ALTER TABLE users 
+    ADD COLUMN handle TEXT,
+    ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+    ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '';
+
+-- Step 2: Give existing users a generated handle
+UPDATE users 
+    SET handle = 'user_' || substr(replace(id::text, '-', ''), 1, 10) 
+    WHERE handle IS NULL;
+
+-- Step 3: Make the handle non-null and unique, ignoring case
+ALTER TABLE users 
+    ALTER COLUMN handle 
+        SET NOT NULL;
+
+CREATE UNIQUE INDEX users_handle_lower_idx 
+    ON users (lower(handle));
+
+-- +goose Down
+DROP INDEX users_handle_lower_idx;
+ALTER TABLE users 
+    DROP COLUMN avatar_url,
+    DROP COLUMN bio,
+    DROP COLUMN display_name,
+    DROP COLUMN handle;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE chirp_mentions (
+    chirp_id UUID NOT NULL 
+        REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, start_offset)
+);
+
+CREATE INDEX chirp_mentions_user_id_idx 
+    ON chirp_mentions (user_id, chirp_id);
+
+-- +goose Down
+DROP TABLE chirp_mentions;
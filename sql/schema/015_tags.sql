@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE tags (
+    name TEXT PRIMARY KEY,
+    created_at  TIMESTAMP NOT NULL,
+    -- trend_score decays over time, it is the score as of trend_updated_at
+    trend_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    trend_updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX tags_trend_updated_at_idx 
+    ON tags (trend_updated_at);
+
+CREATE TABLE chirp_tags (
+    chirp_id UUID NOT NULL 
+        REFERENCES chirps(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL 
+        REFERENCES tags(name) ON DELETE CASCADE,
+    PRIMARY KEY (chirp_id, tag)
+);
+
+CREATE INDEX chirp_tags_tag_idx 
+    ON chirp_tags (tag);
+
+-- +goose Down
+DROP TABLE chirp_tags;
+DROP TABLE tags;
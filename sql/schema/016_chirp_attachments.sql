@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE chirp_attachments (
+    id UUID PRIMARY KEY,
+    created_at  TIMESTAMP NOT NULL,
+    chirp_id UUID NOT NULL 
+        REFERENCES chirps(id) ON DELETE CASCADE,
+    -- ordinal keeps the attachments in the order they were uploaded
+    ordinal INTEGER NOT NULL,
+    content_type TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    thumbnail_key TEXT NOT NULL,
+    thumbnail_width INTEGER NOT NULL,
+    thumbnail_height INTEGER NOT NULL,
+    byte_size INTEGER NOT NULL,
+    alt_text TEXT NOT NULL DEFAULT '',
+    UNIQUE (chirp_id, ordinal)
+);
+
+-- +goose Down
+DROP TABLE chirp_attachments;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE content_filter_rules (
+    id UUID PRIMARY KEY,
+    created_at  TIMESTAMP NOT NULL,
+    updated_at  TIMESTAMP NOT NULL,
+    -- word is stored normalized, see contentfilter.Normalize
+    word TEXT NOT NULL UNIQUE,
+    action TEXT NOT NULL 
+        CHECK (action IN ('mask', 'moderate', 'reject'))
+);
+
+ALTER TABLE chirps 
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'published'
+        CONSTRAINT chirps_status_check CHECK (status IN ('published', 'pending_review', 'rejected'));
+
+CREATE INDEX chirps_pending_review_idx 
+    ON chirps (created_at, id) 
+    WHERE status = 'pending_review';
+
+-- +goose Down
+DROP INDEX chirps_pending_review_idx;
+ALTER TABLE chirps DROP COLUMN status;
+DROP TABLE content_filter_rules;
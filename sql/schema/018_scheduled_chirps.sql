@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE chirps 
+    ADD COLUMN publish_at TIMESTAMP;
+
+ALTER TABLE chirps 
+    DROP CONSTRAINT chirps_status_check,
+    ADD CONSTRAINT chirps_status_check CHECK (status IN ('published', 'pending_review', 'rejected', 'scheduled'));
+
+-- the publisher looks for due chirps, their authors list their own by publish time
+CREATE INDEX chirps_scheduled_publish_at_idx 
+    ON chirps (publish_at, id) 
+    WHERE status = 'scheduled';
+
+CREATE INDEX chirps_scheduled_user_id_idx 
+    ON chirps (user_id, publish_at, id) 
+    WHERE status = 'scheduled';
+
+-- +goose Down
+DROP INDEX chirps_scheduled_user_id_idx;
+DROP INDEX chirps_scheduled_publish_at_idx;
+DELETE FROM chirps WHERE status = 'scheduled';
+ALTER TABLE chirps 
+    DROP CONSTRAINT chirps_status_check,
+    ADD CONSTRAINT chirps_status_check CHECK (status IN ('published', 'pending_review', 'rejected'));
+ALTER TABLE chirps DROP COLUMN publish_at;
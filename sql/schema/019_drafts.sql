@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE drafts (
+    id UUID PRIMARY KEY,
+    created_at  TIMESTAMP NOT NULL,
+    updated_at  TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    -- a draft of a reply loses its parent when the parent is deleted, like a reply does
+    reply_to_id UUID 
+        REFERENCES chirps(id) ON DELETE SET NULL
+);
+
+CREATE INDEX drafts_user_id_created_at_idx 
+    ON drafts (user_id, created_at, id);
+
+-- +goose Down
+DROP TABLE drafts;
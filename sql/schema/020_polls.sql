@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE polls (
+    chirp_id UUID PRIMARY KEY 
+        REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at  TIMESTAMP NOT NULL,
+    closes_at  TIMESTAMP NOT NULL
+);
+
+CREATE TABLE poll_options (
+    id UUID PRIMARY KEY,
+    chirp_id UUID NOT NULL 
+        REFERENCES polls(chirp_id) ON DELETE CASCADE,
+    -- position keeps the options in the order the author wrote them, starting at 1
+    position INTEGER NOT NULL,
+    label TEXT NOT NULL,
+    UNIQUE (chirp_id, position),
+    -- lets poll_votes check that the option belongs to the poll voted on
+    UNIQUE (id, chirp_id)
+);
+
+CREATE TABLE poll_votes (
+    chirp_id UUID NOT NULL 
+        REFERENCES polls(chirp_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    option_id UUID NOT NULL,
+    created_at  TIMESTAMP NOT NULL,
+    -- a user votes once per poll
+    PRIMARY KEY (chirp_id, user_id),
+    CONSTRAINT poll_votes_option_fkey FOREIGN KEY (option_id, chirp_id) 
+        REFERENCES poll_options(id, chirp_id) ON DELETE CASCADE
+);
+
+CREATE INDEX poll_votes_option_id_idx 
+    ON poll_votes (option_id);
+
+-- +goose Down
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE lists (
+    id UUID PRIMARY KEY,
+    created_at  TIMESTAMP NOT NULL,
+    updated_at  TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    -- a private list is visible only to its owner
+    is_private BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX lists_user_id_created_at_idx 
+    ON lists (user_id, created_at, id);
+
+CREATE TABLE list_members (
+    list_id UUID NOT NULL 
+        REFERENCES lists(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    created_at  TIMESTAMP NOT NULL,
+    PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX list_members_user_id_idx 
+    ON list_members (user_id);
+
+-- +goose Down
+DROP TABLE list_members;
+DROP TABLE lists;
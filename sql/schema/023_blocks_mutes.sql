@@ -0,0 +1,59 @@
+-- +goose Up
+CREATE TABLE blocks (
+    blocker_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    created_at  TIMESTAMP NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX blocks_blocked_id_idx 
+    ON blocks (blocked_id);
+
+CREATE TABLE mutes (
+    muter_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    created_at  TIMESTAMP NOT NULL,
+    PRIMARY KEY (muter_id, muted_id),
+    CHECK (muter_id <> muted_id)
+);
+
+-- a block works both ways, neither user sees the other
+-- +goose StatementBegin
+CREATE FUNCTION blocked_between(user_id UUID, other_user_id UUID) RETURNS BOOLEAN
+    LANGUAGE sql STABLE
+    AS $$
+        SELECT EXISTS (
+            SELECT 1 
+                FROM blocks 
+                WHERE (blocker_id = user_id AND blocked_id = other_user_id)
+                    OR (blocker_id = other_user_id AND blocked_id = user_id)
+            )
+    $$;
+-- +goose StatementEnd
+
+-- every chirp listing leaves out the chirps hidden from the viewer,
+-- those of users blocked either way and those of users the viewer muted
+-- +goose StatementBegin
+CREATE FUNCTION hidden_from(author_id UUID, viewer_id UUID) RETURNS BOOLEAN
+    LANGUAGE sql STABLE
+    AS $$
+        SELECT blocked_between(author_id, viewer_id) 
+            OR EXISTS (
+                SELECT 1 
+                    FROM mutes 
+                    WHERE muter_id = viewer_id 
+                        AND muted_id = author_id
+                )
+    $$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION hidden_from;
+DROP FUNCTION blocked_between;
+DROP TABLE mutes;
+DROP TABLE blocks;
@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY,
+    created_at  TIMESTAMP NOT NULL,
+    -- updated_at moves with every message, conversations are listed by their latest activity
+    updated_at  TIMESTAMP NOT NULL,
+    -- a 1:1 conversation is reused when the same two users start another one, a group conversation is not
+    is_group BOOLEAN NOT NULL
+);
+
+CREATE INDEX conversations_updated_at_idx 
+    ON conversations (updated_at, id);
+
+CREATE TABLE conversation_participants (
+    conversation_id UUID NOT NULL 
+        REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    created_at  TIMESTAMP NOT NULL,
+    -- the read receipt: messages up to this time have been read by the participant
+    last_read_at  TIMESTAMP,
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX conversation_participants_user_id_idx 
+    ON conversation_participants (user_id);
+
+CREATE TABLE messages (
+    id UUID PRIMARY KEY,
+    created_at  TIMESTAMP NOT NULL,
+    conversation_id UUID NOT NULL 
+        REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL
+);
+
+CREATE INDEX messages_conversation_id_created_at_idx 
+    ON messages (conversation_id, created_at, id);
+
+-- +goose Down
+DROP TABLE messages;
+DROP TABLE conversation_participants;
+DROP TABLE conversations;
@@ -0,0 +1,42 @@
+-- +goose Up
+-- the notification preferences of a user, one switch per notification type
+ALTER TABLE users 
+    ADD COLUMN notify_likes BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN notify_replies BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN notify_follows BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN notify_mentions BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    created_at  TIMESTAMP NOT NULL,
+    -- user_id is notified of what actor_id did
+    user_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL 
+        CHECK (type IN ('like', 'reply', 'follow', 'mention')),
+    -- the liked chirp, the reply or the chirp with the mention, NULL for a follow
+    chirp_id UUID 
+        REFERENCES chirps(id) ON DELETE CASCADE,
+    read_at  TIMESTAMP
+);
+
+-- an action notifies once: liking a chirp again or mentioning a user again in an edit doesn't notify twice
+CREATE UNIQUE INDEX notifications_once_idx 
+    ON notifications (user_id, type, actor_id, COALESCE(chirp_id, '00000000-0000-0000-0000-000000000000'));
+
+CREATE INDEX notifications_user_id_created_at_idx 
+    ON notifications (user_id, created_at);
+
+CREATE INDEX notifications_unread_idx 
+    ON notifications (user_id) 
+    WHERE read_at IS NULL;
+
+-- +goose Down
+DROP TABLE notifications;
+ALTER TABLE users 
+    DROP COLUMN notify_mentions,
+    DROP COLUMN notify_follows,
+    DROP COLUMN notify_replies,
+    DROP COLUMN notify_likes;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE password_reset_tokens (
+    -- only the SHA-256 of a token is stored, the token itself is only ever in the email
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    created_at  TIMESTAMP NOT NULL,
+    expires_at  TIMESTAMP NOT NULL,
+    -- a token can be used once, resetting the password also uses up the other tokens of the user
+    used_at  TIMESTAMP
+);
+
+CREATE INDEX password_reset_tokens_user_id_created_at_idx 
+    ON password_reset_tokens (user_id, created_at);
+
+-- +goose Down
+DROP TABLE password_reset_tokens;
@@ -0,0 +1,63 @@
+-- +goose Up
+-- notifications of the same type about the same chirp on the same day form a group,
+-- a group is listed at the time it began, so it keeps its place in the list as notifications join it
+CREATE TABLE notification_groups (
+    id UUID PRIMARY KEY,
+    created_at  TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL 
+        REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    -- the chirp the notifications are about: the liked chirp, the chirp replied to, the chirp with the mention
+    -- or, for a mention in a reply, the chirp replied to; NULL for a follow
+    subject_chirp_id UUID 
+        REFERENCES chirps(id) ON DELETE CASCADE,
+    day DATE NOT NULL
+);
+
+CREATE UNIQUE INDEX notification_groups_key_idx 
+    ON notification_groups (user_id, type, COALESCE(subject_chirp_id, '00000000-0000-0000-0000-000000000000'), day);
+
+CREATE INDEX notification_groups_user_id_created_at_idx 
+    ON notification_groups (user_id, created_at DESC, id DESC);
+
+-- the groups of the notifications stored before, see CreateNotifications for the subject of a notification
+CREATE TEMPORARY TABLE notification_subjects AS 
+    SELECT notifications.id, notifications.created_at, notifications.user_id, notifications.type,
+        CASE notifications.type
+            WHEN 'reply' THEN chirps.reply_to_id
+            WHEN 'mention' THEN COALESCE(chirps.reply_to_id, chirps.id)
+            ELSE notifications.chirp_id
+            END AS subject_chirp_id
+        FROM notifications 
+        LEFT JOIN chirps ON chirps.id = notifications.chirp_id;
+
+INSERT INTO notification_groups (id, created_at, user_id, type, subject_chirp_id, day)
+    SELECT gen_random_uuid(), MIN(created_at), user_id, type, subject_chirp_id, created_at::date
+        FROM notification_subjects 
+        GROUP BY user_id, type, subject_chirp_id, created_at::date;
+
+ALTER TABLE notifications 
+    ADD COLUMN group_id UUID 
+        REFERENCES notification_groups(id) ON DELETE CASCADE;
+
+UPDATE notifications 
+    SET group_id = notification_groups.id
+    FROM notification_subjects, notification_groups
+    WHERE notification_subjects.id = notifications.id
+        AND notification_groups.user_id = notification_subjects.user_id
+        AND notification_groups.type = notification_subjects.type
+        AND notification_groups.subject_chirp_id IS NOT DISTINCT FROM notification_subjects.subject_chirp_id
+        AND notification_groups.day = notification_subjects.created_at::date;
+
+DROP TABLE notification_subjects;
+
+ALTER TABLE notifications 
+    ALTER COLUMN group_id SET NOT NULL;
+
+CREATE INDEX notifications_group_id_idx 
+    ON notifications (group_id);
+
+-- +goose Down
+ALTER TABLE notifications 
+    DROP COLUMN group_id;
+DROP TABLE notification_groups;
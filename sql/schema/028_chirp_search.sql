@@ -0,0 +1,48 @@
+-- +goose Up
+-- the search document of a chirp moves to a table of its own,
+-- so that only the search reads it rather than every query selecting chirps
+CREATE TABLE chirp_search (
+    chirp_id UUID PRIMARY KEY 
+        REFERENCES chirps(id) ON DELETE CASCADE,
+    search_vector tsvector NOT NULL
+);
+
+CREATE INDEX chirp_search_vector_idx 
+    ON chirp_search USING GIN (search_vector);
+
+INSERT INTO chirp_search (chirp_id, search_vector)
+    SELECT id, search_vector 
+        FROM chirps;
+
+-- the document follows the body of the chirp, whichever query writes it
+-- +goose StatementBegin
+CREATE FUNCTION index_chirp_search() RETURNS TRIGGER
+    LANGUAGE plpgsql
+    AS $$
+        BEGIN
+            INSERT INTO chirp_search (chirp_id, search_vector)
+                VALUES (NEW.id, to_tsvector('english', NEW.body))
+                ON CONFLICT (chirp_id) DO UPDATE 
+                    SET search_vector = EXCLUDED.search_vector;
+            RETURN NULL;
+        END
+    $$;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_index_search 
+    AFTER INSERT OR UPDATE OF body ON chirps 
+    FOR EACH ROW 
+    EXECUTE FUNCTION index_chirp_search();
+
+DROP INDEX chirps_search_vector_idx;
+ALTER TABLE chirps DROP COLUMN search_vector;
+
+-- +goose Down
+ALTER TABLE chirps 
+    ADD COLUMN search_vector tsvector 
+        GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+CREATE INDEX chirps_search_vector_idx 
+    ON chirps USING GIN (search_vector);
+DROP TRIGGER chirps_index_search ON chirps;
+DROP FUNCTION index_chirp_search;
+DROP TABLE chirp_search;
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/ArrayOfLilly/chirp/internal/auth"
+	"github.com/google/uuid"
+)
+
+// viewerID returns the ID of the user making the request, for endpoints that also serve anonymous readers.
+//
+// It takes an http.Request as a parameter.
+// Returns the user ID from a valid bearer JWT, or uuid.Nil if the request has no token or the token is not valid.
+func (cfg *apiConfig) viewerID(r *http.Request) uuid.UUID {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		return uuid.Nil
+	}
+	return userID
+}